@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addStrategyTeam(t *testing.T, serverURL, teamName, strategy string, userIDs ...string) {
+	t.Helper()
+
+	members := []map[string]interface{}{}
+	for _, id := range userIDs {
+		members = append(members, map[string]interface{}{"user_id": id, "username": id, "is_active": true})
+	}
+	resp, _ := postJSON(t, serverURL+"/team/add", map[string]interface{}{
+		"team_name": teamName,
+		"settings":  map[string]interface{}{"assignment_strategy": strategy},
+		"members":   members,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func createPR(t *testing.T, serverURL, prID, authorID string) []interface{} {
+	t.Helper()
+
+	resp, body := postJSON(t, serverURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   prID,
+		"pull_request_name": prID,
+		"author_id":         authorID,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	return body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+}
+
+func TestAssignment_UnknownStrategy(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "bad",
+		"settings":  map[string]interface{}{"assignment_strategy": "alphabetical"},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", body["error"].(map[string]interface{})["code"])
+}
+
+func TestAssignment_RoundRobin(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addStrategyTeam(t, server.URL, "rr", "round_robin", "a", "b", "c", "d")
+
+	assert.Equal(t, []interface{}{"b", "c"}, createPR(t, server.URL, "rr-1", "a"))
+	assert.Equal(t, []interface{}{"d", "b"}, createPR(t, server.URL, "rr-2", "a"))
+	assert.Equal(t, []interface{}{"c", "d"}, createPR(t, server.URL, "rr-3", "a"))
+}
+
+func TestAssignment_RoundRobinSurvivesRestart(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+
+	addStrategyTeam(t, server.URL, "rr-restart", "round_robin", "a", "b", "c", "d")
+	assert.Equal(t, []interface{}{"b", "c"}, createPR(t, server.URL, "rrs-1", "a"))
+	server.Close()
+
+	// A new process over the same data continues the rotation where the
+	// last recorded assignment left it.
+	restarted := setupTestServer(store)
+	defer restarted.Close()
+	assert.Equal(t, []interface{}{"d", "b"}, createPR(t, restarted.URL, "rrs-2", "a"))
+
+	rotation, err := store.TeamRotation(context.Background(), "rr-restart")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", rotation)
+}
+
+func TestAssignment_LeastLoadedBalancesOpenReviews(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addStrategyTeam(t, server.URL, "ll", "least_loaded", "a", "b", "c", "d")
+
+	for i := 0; i < 6; i++ {
+		createPR(t, server.URL, fmt.Sprintf("ll-%d", i), "a")
+	}
+
+	counts, err := store.GetOpenReviewCounts(context.Background(), []string{"b", "c", "d"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"b": 4, "c": 4, "d": 4}, counts)
+}
+
+func updateSettings(t *testing.T, serverURL string, settings map[string]interface{}) (*http.Response, map[string]interface{}) {
+	t.Helper()
+	return postJSON(t, serverURL+"/team/settings/update", settings)
+}
+
+func TestAssignment_TeamSettings(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "security", "sec-a", "sec-b", "sec-c", "sec-d")
+
+	resp, err := http.Get(server.URL + "/team/settings?team_name=security")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := updateSettings(t, server.URL, map[string]interface{}{
+		"team_name":     "security",
+		"min_reviewers": 4,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", body["error"].(map[string]interface{})["code"])
+
+	resp, body = updateSettings(t, server.URL, map[string]interface{}{
+		"team_name":     "security",
+		"min_reviewers": 3,
+		"max_reviewers": 3,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	settings := body["settings"].(map[string]interface{})
+	assert.Equal(t, float64(3), settings["max_reviewers"])
+	assert.Equal(t, "random", settings["assignment_strategy"])
+
+	assert.Len(t, createPR(t, server.URL, "sec-1", "sec-a"), 3)
+
+	postJSON(t, server.URL+"/users/setIsActive", map[string]interface{}{"user_id": "sec-d", "is_active": false})
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "sec-2",
+		"pull_request_name": "sec-2",
+		"author_id":         "sec-a",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ENOUGH_REVIEWERS", body["error"].(map[string]interface{})["code"])
+}
+
+func TestAssignment_CrossTeamFallback(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "solo", "solo-a")
+	addMemoryTeam(t, server.URL, "platform", "plat-a")
+
+	assert.Empty(t, createPR(t, server.URL, "solo-1", "solo-a"))
+
+	resp, _ := updateSettings(t, server.URL, map[string]interface{}{
+		"team_name":                 "solo",
+		"allow_cross_team_fallback": true,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, []interface{}{"plat-a"}, createPR(t, server.URL, "solo-2", "solo-a"))
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postJSONAs(t *testing.T, actor, url string, body interface{}) *http.Response {
+	t.Helper()
+
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Actor", actor)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+type auditPage struct {
+	Events []struct {
+		ID     int64           `json:"id"`
+		Actor  string          `json:"actor"`
+		Action string          `json:"action"`
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	} `json:"events"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func getAudit(t *testing.T, serverURL string, query url.Values) auditPage {
+	t.Helper()
+
+	resp, err := http.Get(serverURL + "/audit?" + query.Encode())
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page auditPage
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&page))
+	return page
+}
+
+func (p auditPage) actions() []string {
+	var actions []string
+	for _, event := range p.Events {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+func TestAudit_RecordsChangesWithActor(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp := postJSONAs(t, "admin", server.URL+"/team/add", map[string]interface{}{
+		"team_name": "audit-team",
+		"settings":  map[string]interface{}{"max_reviewers": 1, "assignment_strategy": "round_robin"},
+		"members": []map[string]interface{}{
+			{"user_id": "audit-1", "username": "audit-1", "is_active": true},
+			{"user_id": "audit-2", "username": "audit-2", "is_active": true},
+			{"user_id": "audit-3", "username": "audit-3", "is_active": true},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = postJSONAs(t, "ci-bot", server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "audit-pr-1",
+		"pull_request_name": "Audited PR",
+		"author_id":         "audit-1",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp = postJSONAs(t, "lead", server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "audit-pr-1",
+		"old_user_id":     "audit-2",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSONAs(t, "audit-3", server.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "audit-pr-1",
+		"reviewer_id":     "audit-3",
+		"verdict":         "APPROVED",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = postJSONAs(t, "ci-bot", server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "audit-pr-1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	page := getAudit(t, server.URL, url.Values{"pull_request_id": {"audit-pr-1"}})
+	assert.Equal(t, []string{"pr.created", "reviewer.replaced", "review.submitted", "pr.status_changed"}, page.actions())
+	assert.Equal(t, "ci-bot", page.Events[0].Actor)
+	assert.Equal(t, "lead", page.Events[1].Actor)
+	assert.JSONEq(t, `{"reviewer_id": "audit-2"}`, string(page.Events[1].Before))
+	assert.JSONEq(t, `{"reviewer_id": "audit-3"}`, string(page.Events[1].After))
+	assert.JSONEq(t, `{"status": "MERGED"}`, string(page.Events[3].After))
+
+	page = getAudit(t, server.URL, url.Values{"user_id": {"audit-2"}})
+	assert.Equal(t, []string{"user.saved", "pr.created", "reviewer.replaced"}, page.actions())
+
+	page = getAudit(t, server.URL, url.Values{"team_name": {"audit-team"}, "action": {"team.created"}})
+	assert.Len(t, page.Events, 1)
+	assert.Equal(t, "admin", page.Events[0].Actor)
+
+	page = getAudit(t, server.URL, url.Values{"to": {time.Now().Add(-time.Hour).Format(time.RFC3339)}})
+	assert.Empty(t, page.Events)
+}
+
+func TestAudit_CursorPagination(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "page-team", "page-1", "page-2", "page-3")
+
+	var actions []string
+	query := url.Values{"team_name": {"page-team"}, "limit": {"2"}}
+	for pages := 0; pages < 5; pages++ {
+		page := getAudit(t, server.URL, query)
+		actions = append(actions, page.actions()...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	assert.Equal(t, []string{"team.created", "user.saved", "user.saved", "user.saved"}, actions)
+
+	resp, err := http.Get(server.URL + "/audit?cursor=abc")
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addAvailability(t *testing.T, serverURL, userID string, start, end time.Time, reassign bool) map[string]interface{} {
+	t.Helper()
+
+	resp, body := postJSON(t, serverURL+"/users/availability/add", map[string]interface{}{
+		"user_id":          userID,
+		"start":            start,
+		"end":              end,
+		"reason":           "vacation",
+		"reassign_reviews": reassign,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	return body["period"].(map[string]interface{})
+}
+
+func TestAvailability_CRUD(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "av-crud", "avc-a")
+	start := time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	resp, body := postJSON(t, server.URL+"/users/availability/add", map[string]interface{}{
+		"user_id": "avc-a", "start": start, "end": start,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidAvailability, errorCode(body))
+
+	resp, _ = postJSON(t, server.URL+"/users/availability/add", map[string]interface{}{
+		"user_id": "missing", "start": start, "end": start.Add(time.Hour),
+	})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	period := addAvailability(t, server.URL, "avc-a", start, start.AddDate(0, 0, 14), false)
+	assert.Equal(t, "vacation", period["reason"])
+
+	resp, body = postJSON(t, server.URL+"/users/availability/update", map[string]interface{}{
+		"id":     period["id"],
+		"start":  start,
+		"end":    start.AddDate(0, 0, 7),
+		"reason": "conference",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "conference", body["period"].(map[string]interface{})["reason"])
+	assert.Equal(t, "avc-a", body["period"].(map[string]interface{})["user_id"])
+
+	listResp, err := http.Get(server.URL + "/users/availability?user_id=avc-a")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, listResp.StatusCode)
+	listResp.Body.Close()
+
+	resp, _ = postJSON(t, server.URL+"/users/availability/delete", map[string]interface{}{"id": period["id"]})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = postJSON(t, server.URL+"/users/availability/delete", map[string]interface{}{"id": period["id"]})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAvailability_AwayUserIsSkipped(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "av-team", "av-a", "av-b", "av-c", "av-d")
+	now := time.Now()
+	addAvailability(t, server.URL, "av-b", now.Add(-time.Hour), now.Add(24*time.Hour), false)
+	addAvailability(t, server.URL, "av-c", now.Add(24*time.Hour), now.Add(48*time.Hour), false)
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "av-pr",
+		"pull_request_name": "Away",
+		"author_id":         "av-a",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.ElementsMatch(t, []interface{}{"av-c", "av-d"}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+	assert.Equal(t, map[interface{}]interface{}{"av-b": "UNAVAILABLE"}, skippedUsers(body))
+
+	user, err := store.GetUserByID(context.Background(), "av-b")
+	assert.NoError(t, err)
+	assert.True(t, user.IsActive, "an availability period leaves is_active alone")
+}
+
+func TestAvailability_HandoffReassignsOnce(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addTeamWithSettings(t, server.URL, "av-handoff", map[string]interface{}{
+		"max_reviewers": 1,
+	}, "avh-a", "avh-b", "avh-c")
+	away := createPR(t, server.URL, "avh-pr", "avh-a")[0].(string)
+
+	now := time.Now()
+	period := addAvailability(t, server.URL, away, now.Add(-time.Minute), now.Add(time.Hour), true)
+
+	handoff := handlers.NewReviewHandoff(store, assignment.NewAssigner(1))
+	assert.NoError(t, handoff.RunOnce(context.Background()))
+
+	pr, err := store.GetPRByID(context.Background(), "avh-pr")
+	assert.NoError(t, err)
+	assert.Len(t, pr.AssignedReviewers, 1)
+	assert.NotEqual(t, away, pr.AssignedReviewers[0])
+
+	periods, err := store.ListAvailabilityPeriods(context.Background(), away)
+	assert.NoError(t, err)
+	if assert.Len(t, periods, 1) {
+		assert.Equal(t, int64(period["id"].(float64)), periods[0].ID)
+		assert.NotNil(t, periods[0].ReviewsReassignedAt)
+	}
+
+	due, err := store.DueReviewHandoffs(context.Background(), time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, due)
+}
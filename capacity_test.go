@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setMaxOpenReviews(t *testing.T, serverURL, userID string, limit interface{}) {
+	t.Helper()
+
+	resp, _ := postJSON(t, serverURL+"/users/setMaxOpenReviews", map[string]interface{}{
+		"user_id":          userID,
+		"max_open_reviews": limit,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func skippedUsers(body map[string]interface{}) map[interface{}]interface{} {
+	reasons := map[interface{}]interface{}{}
+	for _, entry := range body["skipped_reviewers"].([]interface{}) {
+		skipped := entry.(map[string]interface{})
+		reasons[skipped["user_id"]] = skipped["reason"]
+	}
+	return reasons
+}
+
+func TestCapacity_UserAtLimitIsSkipped(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "cap-team", "cap-a", "cap-b", "cap-c", "cap-d")
+	setMaxOpenReviews(t, server.URL, "cap-b", 0)
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "cap-pr",
+		"pull_request_name": "Capped",
+		"author_id":         "cap-a",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	reviewers := body["pr"].(map[string]interface{})["assigned_reviewers"]
+	assert.ElementsMatch(t, []interface{}{"cap-c", "cap-d"}, reviewers)
+	assert.Equal(t, map[interface{}]interface{}{"cap-b": "AT_CAPACITY"}, skippedUsers(body))
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "cap-pr",
+		"old_user_id":     "cap-c",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the only other member is at capacity")
+	assert.Equal(t, "NO_CANDIDATE", errorCode(body))
+
+	setMaxOpenReviews(t, server.URL, "cap-b", nil)
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "cap-pr",
+		"old_user_id":     "cap-c",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "cap-b", body["replaced_by"])
+	assert.Empty(t, body["skipped_reviewers"])
+}
+
+func TestCapacity_TeamDefaultApplies(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addTeamWithSettings(t, server.URL, "cap-default", map[string]interface{}{
+		"max_open_reviews": 1,
+	}, "cd-a", "cd-b", "cd-c")
+	setMaxOpenReviews(t, server.URL, "cd-c", 2)
+
+	assert.ElementsMatch(t, []interface{}{"cd-b", "cd-c"}, createPR(t, server.URL, "cd-1", "cd-a"))
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "cd-2",
+		"pull_request_name": "Second",
+		"author_id":         "cd-a",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []interface{}{"cd-c"}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+	assert.Equal(t, map[interface{}]interface{}{"cd-b": "AT_CAPACITY"}, skippedUsers(body))
+
+	resp, _ = postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":        "cap-default",
+		"min_reviewers":    1,
+		"max_open_reviews": 1,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "cd-3",
+		"pull_request_name": "Third",
+		"author_id":         "cd-a",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ENOUGH_REVIEWERS", errorCode(body))
+}
+
+func TestCapacity_InvalidLimits(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "cap-invalid", "ci-a")
+
+	resp, body := postJSON(t, server.URL+"/users/setMaxOpenReviews", map[string]interface{}{
+		"user_id":          "ci-a",
+		"max_open_reviews": -1,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", errorCode(body))
+
+	resp, _ = postJSON(t, server.URL+"/users/setMaxOpenReviews", map[string]interface{}{
+		"user_id":          "missing",
+		"max_open_reviews": 1,
+	})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, body = postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":        "cap-invalid",
+		"max_open_reviews": -3,
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", errorCode(body))
+}
+
+func TestCapacity_BulkDeactivateReportsSkipped(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "cap-dst", "cb-d1")
+	addMemoryTeam(t, server.URL, "cap-spare", "cb-p1", "cb-p2")
+	addTeamWithSettings(t, server.URL, "cap-src", map[string]interface{}{
+		"max_reviewers":  1,
+		"fallback_teams": []string{"cap-dst"},
+	}, "cb-a")
+
+	assert.Equal(t, []interface{}{"cb-d1"}, createPR(t, server.URL, "cb-pr", "cb-a"))
+
+	resp, _ := postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":      "cap-src",
+		"fallback_teams": []string{"cap-dst", "cap-spare"},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	setMaxOpenReviews(t, server.URL, "cb-p1", 0)
+
+	resp, body := postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "cap-dst"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	result := body["result"].(map[string]interface{})
+	skipped := result["skipped_reviewers"].(map[string]interface{})["cb-pr"].([]interface{})
+	if assert.Len(t, skipped, 1) {
+		assert.Equal(t, "cb-p1", skipped[0].(map[string]interface{})["user_id"])
+		assert.Equal(t, "AT_CAPACITY", skipped[0].(map[string]interface{})["reason"])
+	}
+
+	pr, err := store.GetPRByID(context.Background(), "cb-pr")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cb-p2"}, pr.AssignedReviewers)
+}
@@ -4,21 +4,129 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"pr-reviewer-service/internal/assignment"
 	"pr-reviewer-service/internal/config"
+	"pr-reviewer-service/internal/events"
 	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/migrate"
 	"pr-reviewer-service/internal/storage"
+	"strconv"
+	"time"
 )
 
-func main() {
-	fmt.Printf("Connecting to database: %s\n", config.DBName)
-	db, err := storage.InitDB()
+const migrateUsage = "usage: server migrate up | down [n] | status | baseline <version>"
+
+// runMigrate implements `server migrate ...` for operating on the schema
+// without starting the HTTP server.
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(migrateUsage)
+	}
+
+	db, err := storage.OpenDB()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		return err
 	}
 	defer db.Close()
-	fmt.Println("Connected to PostgreSQL!")
 
-	store := storage.NewStorage(db)
+	runner, err := migrate.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := runner.Up()
+		for _, m := range applied {
+			fmt.Printf("applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("down: step count must be a positive number")
+			}
+		}
+		reverted, err := runner.Down(steps)
+		for _, m := range reverted {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+		return err
+	case "status":
+		statuses, err := runner.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d_%s\t%s\n", status.Version, status.Name, state)
+		}
+		return nil
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("baseline: version is required")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("baseline: version must be a number")
+		}
+		return runner.Baseline(version)
+	}
+	return fmt.Errorf(migrateUsage)
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	seed := time.Now().UnixNano()
+	if config.AssignmentSeed != 0 {
+		fmt.Printf("Assigning reviewers with seed %d\n", config.AssignmentSeed)
+		seed = config.AssignmentSeed
+	}
+	assigner := assignment.NewAssigner(seed)
+
+	var store storage.Store
+	if config.StorageBackend == "memory" {
+		fmt.Println("Using in-memory storage")
+		store = storage.NewMemoryStorage()
+	} else {
+		fmt.Printf("Connecting to database: %s\n", config.DBName)
+		db, err := storage.InitDB()
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		defer db.Close()
+		fmt.Println("Connected to PostgreSQL!")
+
+		store = storage.NewStorage(db)
+	}
+
+	dispatcher := events.NewDispatcher(store)
+	dispatcher.Start(config.WebhookDeliveryWorkers)
+	defer dispatcher.Stop()
+
+	relay := events.NewRelay(store, events.LogSink{}, dispatcher)
+	relay.Start()
+	defer relay.Stop()
+
+	handoff := handlers.NewReviewHandoff(store, assigner)
+	handoff.Interval = config.ReviewHandoffInterval
+	handoff.Start()
+	defer handoff.Stop()
 
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method == "GET" {
@@ -36,36 +144,140 @@ func main() {
 		handlers.GetTeamHandler(w, r, store)
 	})
 
+	http.HandleFunc("/team/settings", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GetTeamSettingsHandler(w, r, store)
+	})
+
+	http.HandleFunc("/team/settings/update", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UpdateTeamSettingsHandler(w, r, store)
+	})
+
+	http.HandleFunc("/team/codeowners", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UploadCodeownersHandler(w, r, store)
+	})
+
 	http.HandleFunc("/users/setIsActive", func(w http.ResponseWriter, r *http.Request) {
 		handlers.SetUserActiveHandler(w, r, store)
 	})
 
+	http.HandleFunc("/users/setMaxOpenReviews", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SetUserMaxOpenReviewsHandler(w, r, store)
+	})
+
+	http.HandleFunc("/users/setRole", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SetUserRoleHandler(w, r, store)
+	})
+
+	http.HandleFunc("/users/availability", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListAvailabilityHandler(w, r, store)
+	})
+
+	http.HandleFunc("/users/availability/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddAvailabilityHandler(w, r, store)
+	})
+
+	http.HandleFunc("/users/availability/update", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UpdateAvailabilityHandler(w, r, store)
+	})
+
+	http.HandleFunc("/users/availability/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteAvailabilityHandler(w, r, store)
+	})
+
+	http.HandleFunc("/rules/exclusions", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListExclusionsHandler(w, r, store)
+	})
+
+	http.HandleFunc("/rules/exclusions/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddExclusionHandler(w, r, store)
+	})
+
+	http.HandleFunc("/rules/exclusions/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteExclusionHandler(w, r, store)
+	})
+
 	http.HandleFunc("/pullRequest/create", func(w http.ResponseWriter, r *http.Request) {
-		handlers.CreatePRHandler(w, r, store)
+		handlers.CreatePRHandler(w, r, store, assigner)
+	})
+
+	http.HandleFunc("/pullRequest/preview", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PreviewPRHandler(w, r, store, assigner)
 	})
 
 	http.HandleFunc("/pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
 		handlers.MergePRHandler(w, r, store)
 	})
 
+	http.HandleFunc("/pullRequest/review", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReviewPRHandler(w, r, store)
+	})
+
+	http.HandleFunc("/pullRequest/close", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ClosePRHandler(w, r, store)
+	})
+
+	http.HandleFunc("/pullRequest/reopen", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReopenPRHandler(w, r, store, assigner)
+	})
+
+	http.HandleFunc("/pullRequest/ready", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReadyForReviewHandler(w, r, store, assigner)
+	})
+
 	http.HandleFunc("/users/getReview", func(w http.ResponseWriter, r *http.Request) {
 		handlers.GetUserReviewsHandler(w, r, store)
 	})
 
 	http.HandleFunc("/pullRequest/reassign", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ReassignReviewerHandler(w, r, store)
+		handlers.ReassignReviewerHandler(w, r, store, assigner)
 	})
 	http.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
 		handlers.StatsHandler(w, r, store)
 	})
 
 	http.HandleFunc("/users/bulkDeactivate", func(w http.ResponseWriter, r *http.Request) {
-		handlers.BulkDeactivateHandler(w, r, store)
+		handlers.BulkDeactivateHandler(w, r, store, assigner)
 	})
 	http.HandleFunc("/pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
 		handlers.GetPRHandler(w, r, store)
 	})
 
+	http.HandleFunc("/pullRequest/explain", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ExplainPRHandler(w, r, store)
+	})
+
+	http.HandleFunc("/webhooks/github", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GitHubWebhookHandler(w, r, store, assigner)
+	})
+
+	http.HandleFunc("/webhooks/gitlab", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GitLabWebhookHandler(w, r, store, assigner)
+	})
+
+	http.HandleFunc("/webhooks/gitea", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GiteaWebhookHandler(w, r, store, assigner)
+	})
+
+	http.HandleFunc("/subscriptions/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddSubscriptionHandler(w, r, store)
+	})
+
+	http.HandleFunc("/subscriptions/list", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListSubscriptionsHandler(w, r, store)
+	})
+
+	http.HandleFunc("/subscriptions/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteSubscriptionHandler(w, r, store)
+	})
+
+	http.HandleFunc("/subscriptions/deadLetters", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListDeadLettersHandler(w, r, store)
+	})
+
+	http.HandleFunc("/audit", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuditHandler(w, r, store)
+	})
+
 	fmt.Println("Server starting on port 8080...")
-	http.ListenAndServe(":8080", nil)
+	http.ListenAndServe(":8080", handlers.Idempotency(store, http.DefaultServeMux))
 }
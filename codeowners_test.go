@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"pr-reviewer-service/internal/codeowners"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testCodeowners = `
+# Default owner
+*                 @co-b
+/docs/            @co-c @co-e
+*.go              @co-d
+/api/**/*.proto   @acme/co-protos
+/vendor/          # nobody owns vendored code
+`
+
+func uploadCodeowners(t *testing.T, serverURL, teamName, file string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	resp, err := http.Post(serverURL+"/team/codeowners?team_name="+teamName, "text/plain", strings.NewReader(file))
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	resp.Body.Close()
+	return resp, decoded
+}
+
+func createPRWithFiles(t *testing.T, serverURL, prID, authorID string, files ...string) []interface{} {
+	t.Helper()
+
+	resp, body := postJSON(t, serverURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   prID,
+		"pull_request_name": prID,
+		"author_id":         authorID,
+		"changed_files":     files,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	return body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+}
+
+func TestCodeowners_Parse(t *testing.T) {
+	rules, err := codeowners.Parse(strings.NewReader(testCodeowners))
+	assert.NoError(t, err)
+	assert.Len(t, rules, 5)
+	assert.Equal(t, []string{"acme/co-protos"}, rules[3].Owners)
+	assert.Empty(t, rules[4].Owners)
+
+	owners := codeowners.Owners(rules, []string{
+		"docs/guide/intro.md", "cmd/main.go", "api/v1/user.proto", "vendor/lib/x.go", "README.md",
+	})
+	assert.Equal(t, []string{"co-c", "co-e", "co-d", "acme/co-protos", "co-b"}, owners)
+
+	_, err = codeowners.Parse(strings.NewReader("*.go @a\n[abc @b\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestCodeowners_PatternMatching(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		matches bool
+	}{
+		{"docs/*", "docs/intro.md", true},
+		{"docs/*", "docs/build/x.md", false},
+		{"docs/*", "src/docs/intro.md", false},
+		{"/docs/", "docs/intro.md", true},
+		{"/docs/", "docs/build/x.md", true},
+		{"/docs/", "docs", false},
+		{"/docs/", "src/docs/intro.md", false},
+		{"**/logs", "logs", true},
+		{"**/logs", "app/logs", true},
+		{"**/logs", "app/logs/today.log", true},
+		{"**/logs", "app/logs.txt", false},
+		{"*.js", "app.js", true},
+		{"*.js", "web/src/app.js", true},
+		{"*.js", "app.jsx", false},
+	}
+
+	for _, tt := range tests {
+		rules := []models.OwnershipRule{{Pattern: tt.pattern, Owners: []string{"owner"}}}
+		owners := codeowners.Owners(rules, []string{tt.path})
+		assert.Equal(t, tt.matches, len(owners) == 1, "%s against %s", tt.pattern, tt.path)
+	}
+}
+
+func TestCodeowners_OwnersArePreferred(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "co-protos", "cp-1")
+	addTeamWithSettings(t, server.URL, "co-team", map[string]interface{}{
+		"max_reviewers": 1,
+	}, "co-a", "co-b", "co-c", "co-d", "co-e", "co-f")
+
+	resp, body := uploadCodeowners(t, server.URL, "co-team", testCodeowners)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, body["settings"].(map[string]interface{})["ownership_rules"], 5)
+
+	assert.Equal(t, []interface{}{"co-d"}, createPRWithFiles(t, server.URL, "co-go", "co-a", "internal/app/server.go"))
+	assert.Equal(t, []interface{}{"cp-1"}, createPRWithFiles(t, server.URL, "co-proto", "co-a", "api/v2/billing.proto"))
+	assert.Equal(t, []interface{}{"co-b"}, createPRWithFiles(t, server.URL, "co-readme", "co-a", "README.md"))
+
+	docs := createPRWithFiles(t, server.URL, "co-docs", "co-a", "docs/setup.md")
+	assert.Subset(t, []interface{}{"co-c", "co-e"}, docs)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "co-docs",
+		"old_user_id":     docs[0],
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Subset(t, []interface{}{"co-c", "co-e"}, []interface{}{body["replaced_by"]}, "the other docs owner takes over")
+	assert.NotEqual(t, docs[0], body["replaced_by"])
+}
+
+func TestCodeowners_TeamPoolFillsTheRest(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "cf-team", "cf-a", "cf-b", "cf-c")
+	resp, _ := postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":       "cf-team",
+		"ownership_rules": []map[string]interface{}{{"pattern": "src/", "owners": []string{"cf-b", "cf-a"}}},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	reviewers := createPRWithFiles(t, server.URL, "cf-pr", "cf-a", "src/main.c")
+	assert.ElementsMatch(t, []interface{}{"cf-b", "cf-c"}, reviewers, "the author owns src/ too but never reviews")
+}
+
+func TestCodeowners_UploadErrors(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "cu-team", "cu-a")
+
+	resp, body := uploadCodeowners(t, server.URL, "cu-team", "/src/[unterminated @cu-a\n")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", errorCode(body))
+
+	resp, _ = uploadCodeowners(t, server.URL, "missing", "* @cu-a\n")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var form bytes.Buffer
+	writer := multipart.NewWriter(&form)
+	part, _ := writer.CreateFormFile("file", "CODEOWNERS")
+	part.Write([]byte("* @cu-a\n"))
+	writer.Close()
+
+	resp, err := http.Post(server.URL+"/team/codeowners?team_name=cu-team", writer.FormDataContentType(), &form)
+	assert.NoError(t, err)
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	rules := body["settings"].(map[string]interface{})["ownership_rules"].([]interface{})
+	assert.Equal(t, "*", rules[0].(map[string]interface{})["pattern"])
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// slowStore widens the gap between reading a PR's candidates or the team's
+// rotation and writing the selection, so that unsynchronized reassigns and
+// creates would visibly collide.
+type slowStore struct {
+	storage.Store
+}
+
+func (s slowStore) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	time.Sleep(time.Millisecond)
+	return s.Store.GetOpenReviewCounts(ctx, userIDs)
+}
+
+func (s slowStore) TeamRotationForUpdate(ctx context.Context, teamName string) (string, error) {
+	rotation, err := s.Store.TeamRotationForUpdate(ctx, teamName)
+	time.Sleep(time.Millisecond)
+	return rotation, err
+}
+
+func (s slowStore) WithActor(actor string) storage.Store {
+	return slowStore{s.Store.WithActor(actor)}
+}
+
+func (s slowStore) InTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	return s.Store.InTx(ctx, func(tx storage.Store) error {
+		return fn(slowStore{tx})
+	})
+}
+
+func getPRWithHistory(t *testing.T, serverURL, prID string) ([]interface{}, []interface{}) {
+	t.Helper()
+
+	resp, err := http.Get(serverURL + "/pullRequest/get?pull_request_id=" + prID)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	pr, _ := body["pr"].(map[string]interface{})
+	reviewers, _ := pr["assigned_reviewers"].([]interface{})
+	history, _ := body["reviewer_history"].([]interface{})
+	return reviewers, history
+}
+
+// hammerReassign has parallel clients repeatedly reassign whoever currently
+// reviews the PR, then checks that every accepted reassign is in the history
+// and the final reviewer set is still valid.
+func hammerReassign(t *testing.T, serverURL, prID, authorID string, reviewerCount int) {
+	t.Helper()
+
+	const workers, rounds = 16, 10
+
+	var mu sync.Mutex
+	statuses := map[int]int{}
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				current, _ := getPRWithHistory(t, serverURL, prID)
+				if len(current) == 0 {
+					continue
+				}
+				resp, _ := postJSON(t, serverURL+"/pullRequest/reassign", map[string]interface{}{
+					"pull_request_id": prID,
+					"old_user_id":     current[(w+i)%len(current)],
+				})
+				mu.Lock()
+				statuses[resp.StatusCode]++
+				mu.Unlock()
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Losing a race means the reviewer was already replaced, never a failure.
+	assert.Equal(t, workers*rounds, statuses[http.StatusOK]+statuses[http.StatusConflict], "statuses: %v", statuses)
+	assert.NotZero(t, statuses[http.StatusOK])
+
+	reviewers, history := getPRWithHistory(t, serverURL, prID)
+	assert.Len(t, reviewers, reviewerCount)
+	seen := map[interface{}]bool{}
+	for _, reviewer := range reviewers {
+		assert.False(t, seen[reviewer], "reviewer %v assigned twice", reviewer)
+		assert.NotEqual(t, authorID, reviewer)
+		seen[reviewer] = true
+	}
+
+	states := map[interface{}]int{}
+	for _, entry := range history {
+		states[entry.(map[string]interface{})["state"]]++
+	}
+	assert.Equal(t, reviewerCount, states["ASSIGNED"])
+	assert.Equal(t, statuses[http.StatusOK], states["REPLACED"])
+}
+
+// raceRoundRobin creates PRs in parallel for a round robin team with exactly
+// enough reviewers for one lap and checks that no reviewer was picked twice.
+func raceRoundRobin(t *testing.T, serverURL, suffix string) {
+	t.Helper()
+
+	const prs = 4
+
+	author := "rr-race-author" + suffix
+	members := []string{author}
+	for i := 1; i <= prs*2; i++ {
+		members = append(members, fmt.Sprintf("rr-race-%d%s", i, suffix))
+	}
+	addStrategyTeam(t, serverURL, "rr-race"+suffix, "round_robin", members...)
+
+	var mu sync.Mutex
+	picked := map[interface{}]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < prs; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reviewers := createPR(t, serverURL, fmt.Sprintf("rr-race-pr-%d%s", i, suffix), author)
+			mu.Lock()
+			for _, reviewer := range reviewers {
+				picked[reviewer]++
+			}
+			mu.Unlock()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Len(t, picked, prs*2, "every create continues the rotation: %v", picked)
+}
+
+func TestConcurrency_ParallelRoundRobinCreatesTakeTurns(t *testing.T) {
+	server := setupTestServer(slowStore{storage.NewMemoryStorage()})
+	defer server.Close()
+
+	raceRoundRobin(t, server.URL, "")
+}
+
+func TestConcurrency_ParallelReassignLosesNothing(t *testing.T) {
+	server := setupTestServer(slowStore{storage.NewMemoryStorage()})
+	defer server.Close()
+
+	members := []string{"race-author"}
+	for i := 1; i <= 12; i++ {
+		members = append(members, fmt.Sprintf("race-%d", i))
+	}
+	addStrategyTeam(t, server.URL, "race", "least_loaded", members...)
+	reviewers := createPR(t, server.URL, "race-pr", "race-author")
+
+	hammerReassign(t, server.URL, "race-pr", "race-author", len(reviewers))
+}
+
+func TestConcurrency_ParallelCreateSameID(t *testing.T) {
+	server := setupTestServer(slowStore{storage.NewMemoryStorage()})
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "dup", "dup-author", "dup-1", "dup-2")
+
+	var mu sync.Mutex
+	codes := map[interface{}]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+				"pull_request_id":   "dup-pr",
+				"pull_request_name": "Duplicate",
+				"author_id":         "dup-author",
+			})
+			code := interface{}(resp.StatusCode)
+			if errBody, ok := body["error"].(map[string]interface{}); ok {
+				code = errBody["code"]
+			}
+			mu.Lock()
+			codes[code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[interface{}]int{http.StatusCreated: 1, "PR_EXISTS": 19}, codes)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_CanceledRequestHasOwnCode(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "ctx-team", "ctx-a", "ctx-b")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	body := `{"pull_request_id":"ctx-pr","pull_request_name":"Canceled","author_id":"ctx-a"}`
+	req := httptest.NewRequest("POST", "/pullRequest/create", strings.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handlers.CreatePRHandler(rec, req, store, assignment.NewAssigner(1))
+
+	var response handlers.ErrorResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, 499, rec.Code)
+	assert.Equal(t, handlers.ErrorRequestCanceled, response.Error.Code)
+
+	pr, err := store.GetPRByID(context.Background(), "ctx-pr")
+	assert.NoError(t, err)
+	assert.Nil(t, pr, "a canceled request must not create the PR")
+}
+
+func TestContext_DeadlineExceededIsTimeout(t *testing.T) {
+	store := storage.NewMemoryStorage()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	req := httptest.NewRequest("GET", "/team/get?team_name=any", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	handlers.GetTeamHandler(rec, req, store)
+
+	var response handlers.ErrorResponse
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+	assert.Equal(t, handlers.ErrorTimeout, response.Error.Code)
+}
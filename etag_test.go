@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postIfMatch(t *testing.T, url, etag string, body interface{}) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	resp.Body.Close()
+	return resp, decoded
+}
+
+func getETag(t *testing.T, url string) string {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	return resp.Header.Get("ETag")
+}
+
+func errorCode(body map[string]interface{}) interface{} {
+	return body["error"].(map[string]interface{})["code"]
+}
+
+func TestETag_ReassignRejectsStaleVersion(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "etag-team", "etag-a", "etag-b", "etag-c", "etag-d", "etag-e")
+	reviewers := createPR(t, server.URL, "etag-pr", "etag-a")
+
+	prURL := server.URL + "/pullRequest/get?pull_request_id=etag-pr"
+	seen := getETag(t, prURL)
+	assert.Equal(t, `"1"`, seen)
+
+	// Someone else reassigns first; the PR moves to version 2.
+	resp, body := postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "etag-pr",
+		"old_user_id":     reviewers[0],
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+	current := body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+
+	resp, body = postIfMatch(t, server.URL+"/pullRequest/reassign", seen, map[string]interface{}{
+		"pull_request_id": "etag-pr",
+		"old_user_id":     current[1],
+	})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorPreconditionFailed, errorCode(body))
+
+	resp, _ = postIfMatch(t, server.URL+"/pullRequest/reassign", getETag(t, prURL), map[string]interface{}{
+		"pull_request_id": "etag-pr",
+		"old_user_id":     current[1],
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+}
+
+func TestETag_MergeRequiresCurrentVersion(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "etag-merge", "em-a", "em-b")
+	createPR(t, server.URL, "etag-merge-pr", "em-a")
+
+	for _, etag := range []string{`"7"`, `W/"1"`, `garbage`} {
+		resp, body := postIfMatch(t, server.URL+"/pullRequest/merge", etag, map[string]interface{}{
+			"pull_request_id": "etag-merge-pr",
+		})
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, etag)
+		assert.Equal(t, handlers.ErrorPreconditionFailed, errorCode(body), etag)
+	}
+
+	resp, body := postIfMatch(t, server.URL+"/pullRequest/merge", "*", map[string]interface{}{
+		"pull_request_id": "etag-merge-pr",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MERGED", body["pr"].(map[string]interface{})["status"])
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+}
+
+func TestETag_TeamSettingsUpdate(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "etag-settings", "es-a", "es-b")
+
+	settingsURL := server.URL + "/team/settings?team_name=etag-settings"
+	seen := getETag(t, settingsURL)
+	assert.Equal(t, seen, getETag(t, server.URL+"/team/get?team_name=etag-settings"))
+
+	resp, _ := postJSON(t, server.URL+"/users/setIsActive", map[string]interface{}{
+		"user_id":   "es-b",
+		"is_active": false,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := postIfMatch(t, server.URL+"/team/settings/update", seen, map[string]interface{}{
+		"team_name":     "etag-settings",
+		"max_reviewers": 1,
+	})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, "a member change bumps the team version")
+	assert.Equal(t, handlers.ErrorPreconditionFailed, errorCode(body))
+
+	seen = getETag(t, settingsURL)
+	resp, body = postIfMatch(t, server.URL+"/team/settings/update", seen, map[string]interface{}{
+		"team_name":     "etag-settings",
+		"max_reviewers": 1,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, float64(1), body["settings"].(map[string]interface{})["max_reviewers"])
+	assert.NotEqual(t, seen, resp.Header.Get("ETag"))
+	assert.Equal(t, resp.Header.Get("ETag"), getETag(t, settingsURL))
+}
+
+func TestETag_StatusChangeRejectsStaleVersion(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "etag-status", "etag-s1", "etag-s2")
+	createPR(t, server.URL, "etag-status-pr", "etag-s1")
+
+	prURL := server.URL + "/pullRequest/get?pull_request_id=etag-status-pr"
+	seen := getETag(t, prURL)
+
+	resp, _ := postJSON(t, server.URL+"/pullRequest/close", map[string]interface{}{"pull_request_id": "etag-status-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body := postIfMatch(t, server.URL+"/pullRequest/reopen", seen, map[string]interface{}{"pull_request_id": "etag-status-pr"})
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorPreconditionFailed, errorCode(body))
+
+	resp, _ = postIfMatch(t, server.URL+"/pullRequest/reopen", getETag(t, prURL), map[string]interface{}{"pull_request_id": "etag-status-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
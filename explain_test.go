@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"testing/quick"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testStrategies = []string{
+	assignment.StrategyRandom, assignment.StrategyRoundRobin, assignment.StrategyLeastLoaded, assignment.StrategyWeighted,
+}
+
+// testInputs builds a selection over one candidate per load, for quick.
+func testInputs(strategy uint8, loads []uint8, count uint8) assignment.Inputs {
+	var pool []assignment.Candidate
+	for i, load := range loads {
+		pool = append(pool, assignment.Candidate{UserID: fmt.Sprintf("u%02d", i), OpenReviews: int(load % 8)})
+	}
+	settings := models.TeamSettings{TeamName: "quick", AssignmentStrategy: testStrategies[int(strategy)%len(testStrategies)]}
+	return assignment.NewInputs(settings, [][]assignment.Candidate{pool}, assignment.Needs{}, int(count%5), "")
+}
+
+func TestExplain_ReplaysAssignments(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addStrategyTeam(t, server.URL, "xp-team", "weighted", "xp-a", "xp-b", "xp-c", "xp-d", "xp-e")
+	reviewers := createPR(t, server.URL, "xp-pr", "xp-a")
+	resp, _ := postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "xp-pr",
+		"old_user_id":     reviewers[0],
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	explainResp, err := http.Get(server.URL + "/pullRequest/explain?pull_request_id=xp-pr")
+	assert.NoError(t, err)
+	var body map[string]interface{}
+	json.NewDecoder(explainResp.Body).Decode(&body)
+	explainResp.Body.Close()
+	assert.Equal(t, http.StatusOK, explainResp.StatusCode)
+
+	assignments := body["assignments"].([]interface{})
+	if assert.Len(t, assignments, 2) {
+		created := assignments[0].(map[string]interface{})
+		assert.Equal(t, "create", created["assigned_by"])
+		assert.Equal(t, "weighted", created["strategy"])
+		assert.Equal(t, reviewers, created["reviewers"])
+		assert.Equal(t, true, created["reproduced"])
+
+		reassigned := assignments[1].(map[string]interface{})
+		assert.Equal(t, "reassign", reassigned["assigned_by"])
+		assert.Equal(t, true, reassigned["reproduced"])
+	}
+
+	explainResp, err = http.Get(server.URL + "/pullRequest/explain?pull_request_id=missing")
+	assert.NoError(t, err)
+	explainResp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, explainResp.StatusCode)
+}
+
+func TestAssignment_ReplayGivesSameResult(t *testing.T) {
+	property := func(seed int64, strategy uint8, loads []uint8, count uint8) bool {
+		inputs, result := assignment.NewAssigner(seed).Run(testInputs(strategy, loads, count))
+
+		encoded, err := json.Marshal(inputs)
+		if err != nil {
+			return false
+		}
+		var decoded assignment.Inputs
+		if err := json.Unmarshal(encoded, &decoded); err != nil {
+			return false
+		}
+		return assert.ObjectsAreEqual(result.Reviewers, assignment.Replay(decoded).Reviewers)
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func TestAssignment_FixedSeedIsDeterministic(t *testing.T) {
+	property := func(seed int64, strategy uint8, loads []uint8, count uint8) bool {
+		first, second := assignment.NewAssigner(seed), assignment.NewAssigner(seed)
+		for i := 0; i < 3; i++ {
+			_, a := first.Run(testInputs(strategy, loads, count))
+			_, b := second.Run(testInputs(strategy, loads, count))
+			if !assert.ObjectsAreEqual(a.Reviewers, b.Reviewers) {
+				return false
+			}
+		}
+		return true
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func TestAssignment_LeastLoadedNeverSkipsALighterCandidate(t *testing.T) {
+	property := func(seed int64, loads []uint8, count uint8) bool {
+		inputs := testInputs(0, loads, count)
+		inputs.Strategy = assignment.StrategyLeastLoaded
+		_, result := assignment.NewAssigner(seed).Run(inputs)
+
+		heaviest := -1
+		for _, candidate := range inputs.Pools[0] {
+			if containsID(result.Reviewers, candidate.UserID) {
+				heaviest = max(heaviest, candidate.OpenReviews)
+			}
+		}
+		for _, candidate := range inputs.Pools[0] {
+			if !containsID(result.Reviewers, candidate.UserID) && candidate.OpenReviews < heaviest {
+				return false
+			}
+		}
+		return len(result.Reviewers) == min(len(loads), inputs.Count)
+	}
+	assert.NoError(t, quick.Check(property, nil))
+}
+
+func containsID(ids []string, id string) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addTeamWithSettings(t *testing.T, serverURL, teamName string, settings map[string]interface{}, userIDs ...string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	members := []map[string]interface{}{}
+	for _, id := range userIDs {
+		members = append(members, map[string]interface{}{"user_id": id, "username": id, "is_active": true})
+	}
+	return postJSON(t, serverURL+"/team/add", map[string]interface{}{
+		"team_name": teamName,
+		"settings":  settings,
+		"members":   members,
+	})
+}
+
+func TestFallback_SoloTeamDrawsFromPartnersInOrder(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "partner-first", "pf-1")
+	addMemoryTeam(t, server.URL, "partner-second", "ps-1", "ps-2")
+	resp, _ := addTeamWithSettings(t, server.URL, "solo", map[string]interface{}{
+		"max_reviewers":  2,
+		"fallback_teams": []string{"partner-first", "partner-second"},
+	}, "solo-1")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	reviewers := createPR(t, server.URL, "solo-pr", "solo-1")
+	assert.Len(t, reviewers, 2)
+	assert.Contains(t, reviewers, "pf-1", "the first partner team is drawn from before the second")
+
+	history, err := store.GetPRReviewerHistory(context.Background(), "solo-pr")
+	assert.NoError(t, err)
+	for _, entry := range history {
+		if assert.NotNil(t, entry.SourceTeam) {
+			if entry.UserID == "pf-1" {
+				assert.Equal(t, "partner-first", *entry.SourceTeam)
+			} else {
+				assert.Equal(t, "partner-second", *entry.SourceTeam)
+			}
+		}
+	}
+}
+
+func TestFallback_HomeTeamIsPreferred(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "fb-partner", "fbp-1", "fbp-2")
+	addTeamWithSettings(t, server.URL, "fb-home", map[string]interface{}{
+		"max_reviewers":  2,
+		"fallback_teams": []string{"fb-partner"},
+	}, "fbh-1", "fbh-2", "fbh-3")
+
+	reviewers := createPR(t, server.URL, "fb-pr", "fbh-1")
+	assert.ElementsMatch(t, []interface{}{"fbh-2", "fbh-3"}, reviewers)
+}
+
+func TestFallback_InvalidFallbackTeams(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := addTeamWithSettings(t, server.URL, "fb-bad", map[string]interface{}{
+		"fallback_teams": []string{"missing"},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_SETTINGS", errorCode(body))
+
+	addMemoryTeam(t, server.URL, "fb-self", "fbs-1")
+	for _, teams := range [][]string{{"fb-self"}, {"missing"}} {
+		resp, body = postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+			"team_name":      "fb-self",
+			"fallback_teams": teams,
+		})
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "INVALID_SETTINGS", errorCode(body))
+	}
+
+	addMemoryTeam(t, server.URL, "fb-other", "fbo-1")
+	resp, body = postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":      "fb-self",
+		"fallback_teams": []string{"fb-other"},
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []interface{}{"fb-other"}, body["settings"].(map[string]interface{})["fallback_teams"])
+
+	reviewers := createPR(t, server.URL, "fb-self-pr", "fbs-1")
+	assert.Equal(t, []interface{}{"fbo-1"}, reviewers)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postWithKey(t *testing.T, url, key string, body interface{}) (*http.Response, []byte) {
+	t.Helper()
+
+	jsonData, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", key)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	raw, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	return resp, raw
+}
+
+// flakyStore fails the first team lookups, as if the database blipped.
+type flakyStore struct {
+	storage.Store
+	failures *int
+}
+
+func (s *flakyStore) WithActor(actor string) storage.Store {
+	return &flakyStore{Store: s.Store.WithActor(actor), failures: s.failures}
+}
+
+func (s *flakyStore) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	if *s.failures > 0 {
+		*s.failures--
+		return nil, errors.New("connection reset")
+	}
+	return s.Store.GetTeam(ctx, teamName)
+}
+
+func TestIdempotency_RetriedCreateReplaysFirstResponse(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "idem-team", "idem-a", "idem-b", "idem-c")
+
+	request := map[string]interface{}{
+		"pull_request_id":   "idem-pr",
+		"pull_request_name": "Retried",
+		"author_id":         "idem-a",
+	}
+	first, firstBody := postWithKey(t, server.URL+"/pullRequest/create", "ci-run-1", request)
+	assert.Equal(t, http.StatusCreated, first.StatusCode)
+	assert.Empty(t, first.Header.Get("Idempotent-Replayed"))
+
+	retry, retryBody := postWithKey(t, server.URL+"/pullRequest/create", "ci-run-1", request)
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Header.Get("ETag"), retry.Header.Get("ETag"))
+	assert.JSONEq(t, string(firstBody), string(retryBody))
+
+	resp, _ := postWithKey(t, server.URL+"/pullRequest/create", "ci-run-2", request)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "a new key runs the request again")
+}
+
+func TestIdempotency_RetriedReassignDoesNotReassignTwice(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "idem-re", "ir-a", "ir-b", "ir-c", "ir-d", "ir-e", "ir-f")
+	reviewers := createPR(t, server.URL, "idem-re-pr", "ir-a")
+
+	request := map[string]interface{}{"pull_request_id": "idem-re-pr", "old_user_id": reviewers[0]}
+	var replacedBy []interface{}
+	for i := 0; i < 3; i++ {
+		resp, raw := postWithKey(t, server.URL+"/pullRequest/reassign", "reassign-1", request)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(raw, &body))
+		replacedBy = append(replacedBy, body["replaced_by"])
+	}
+	assert.Equal(t, replacedBy[0], replacedBy[1])
+	assert.Equal(t, replacedBy[0], replacedBy[2])
+
+	history, err := store.GetPRReviewerHistory(context.Background(), "idem-re-pr")
+	assert.NoError(t, err)
+	replaced := 0
+	for _, entry := range history {
+		if entry.State == models.ReviewerStateReplaced {
+			replaced++
+		}
+	}
+	assert.Equal(t, 1, replaced)
+}
+
+func TestIdempotency_KeyReusedForDifferentRequest(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "idem-reuse", "iu-a", "iu-b")
+
+	resp, _ := postWithKey(t, server.URL+"/pullRequest/create", "shared", map[string]interface{}{
+		"pull_request_id": "iu-1", "pull_request_name": "One", "author_id": "iu-a",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, raw := postWithKey(t, server.URL+"/pullRequest/create", "shared", map[string]interface{}{
+		"pull_request_id": "iu-2", "pull_request_name": "Two", "author_id": "iu-a",
+	})
+	var body handlers.ErrorResponse
+	assert.NoError(t, json.Unmarshal(raw, &body))
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorIdempotencyKeyReused, body.Error.Code)
+
+	resp, _ = postWithKey(t, server.URL+"/pullRequest/merge", "shared", map[string]interface{}{"pull_request_id": "iu-1"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode, "the key is bound to the endpoint too")
+}
+
+func TestIdempotency_ServerErrorIsNotReplayed(t *testing.T) {
+	failures := 1
+	server := setupTestServer(&flakyStore{Store: storage.NewMemoryStorage(), failures: &failures})
+	defer server.Close()
+
+	request := map[string]interface{}{"team_name": "idem-flaky"}
+	resp, _ := postWithKey(t, server.URL+"/team/add", "team-1", request)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	resp, _ = postWithKey(t, server.URL+"/team/add", "team-1", request)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_KeyInUseWhileFirstRequestRuns(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	existing, err := store.ClaimIdempotencyKey(ctx, "busy", "hash", time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = store.ClaimIdempotencyKey(ctx, "busy", "hash", time.Minute)
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, 0, existing.StatusCode)
+	}
+
+	assert.NoError(t, store.ReleaseIdempotencyKey(ctx, "busy"))
+	existing, err = store.ClaimIdempotencyKey(ctx, "busy", "hash", time.Minute)
+	assert.NoError(t, err)
+	assert.Nil(t, existing, "a released key can be claimed again")
+}
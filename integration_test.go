@@ -1,3 +1,5 @@
+//go:build integration
+
 package main
 
 import (
@@ -5,10 +7,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/http/httptest"
 	"testing"
+	"time"
 
-	"pr-reviewer-service/internal/handlers"
 	"pr-reviewer-service/internal/storage"
 
 	"github.com/stretchr/testify/assert"
@@ -163,56 +164,41 @@ func TestIntegration_Stats(t *testing.T) {
 	assert.NotNil(t, statsResponse["stats"])
 }
 
-func setupTestServer(store *storage.Storage) *httptest.Server {
-	mux := http.NewServeMux()
-
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == "GET" {
-			fmt.Fprintf(w, "PR Reviewer Service is working!")
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
-
-	mux.HandleFunc("/team/add", func(w http.ResponseWriter, r *http.Request) {
-		handlers.AddTeamHandler(w, r, store)
-	})
-
-	mux.HandleFunc("/team/get", func(w http.ResponseWriter, r *http.Request) {
-		handlers.GetTeamHandler(w, r, store)
-	})
-
-	mux.HandleFunc("/users/setIsActive", func(w http.ResponseWriter, r *http.Request) {
-		handlers.SetUserActiveHandler(w, r, store)
-	})
-
-	mux.HandleFunc("/pullRequest/create", func(w http.ResponseWriter, r *http.Request) {
-		handlers.CreatePRHandler(w, r, store)
-	})
+func TestIntegration_ConcurrentReassign(t *testing.T) {
+	db, err := storage.InitDB()
+	assert.NoError(t, err)
+	defer db.Close()
 
-	mux.HandleFunc("/pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
-		handlers.MergePRHandler(w, r, store)
-	})
+	server := setupTestServer(slowStore{storage.NewStorage(db)})
+	defer server.Close()
 
-	mux.HandleFunc("/users/getReview", func(w http.ResponseWriter, r *http.Request) {
-		handlers.GetUserReviewsHandler(w, r, store)
-	})
+	suffix := fmt.Sprint(time.Now().UnixNano())
+	author := "race-author-" + suffix
+	members := []string{author}
+	for i := 1; i <= 8; i++ {
+		members = append(members, fmt.Sprintf("race-%d-%s", i, suffix))
+	}
+	addStrategyTeam(t, server.URL, "race-"+suffix, "least_loaded", members...)
+	reviewers := createPR(t, server.URL, "race-pr-"+suffix, author)
 
-	mux.HandleFunc("/pullRequest/reassign", func(w http.ResponseWriter, r *http.Request) {
-		handlers.ReassignReviewerHandler(w, r, store)
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "race-pr-" + suffix,
+		"pull_request_name": "Duplicate",
+		"author_id":         author,
 	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "PR_EXISTS", body["error"].(map[string]interface{})["code"])
 
-	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
-		handlers.StatsHandler(w, r, store)
-	})
+	hammerReassign(t, server.URL, "race-pr-"+suffix, author, len(reviewers))
+}
 
-	mux.HandleFunc("/users/bulkDeactivate", func(w http.ResponseWriter, r *http.Request) {
-		handlers.BulkDeactivateHandler(w, r, store)
-	})
+func TestIntegration_ConcurrentRoundRobin(t *testing.T) {
+	db, err := storage.InitDB()
+	assert.NoError(t, err)
+	defer db.Close()
 
-	mux.HandleFunc("/pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
-		handlers.GetPRHandler(w, r, store)
-	})
+	server := setupTestServer(slowStore{storage.NewStorage(db)})
+	defer server.Close()
 
-	return httptest.NewServer(mux)
+	raceRoundRobin(t, server.URL, fmt.Sprint("-", time.Now().UnixNano()))
 }
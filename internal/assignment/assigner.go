@@ -0,0 +1,151 @@
+package assignment
+
+import (
+	"encoding/json"
+	"math/rand"
+	"sync"
+
+	"pr-reviewer-service/internal/models"
+)
+
+// Draw is what a selection started from: the seed its Assigner gave it and
+// where the team's round robin stood, as the team's last assignment record
+// left it.
+type Draw struct {
+	Seed     int64  `json:"seed"`
+	Rotation string `json:"rotation,omitempty"`
+}
+
+// Inputs is everything one selection depends on. Replaying the same Inputs
+// gives the same Result.
+type Inputs struct {
+	Team     string        `json:"team"`
+	Strategy string        `json:"strategy"`
+	Pools    [][]Candidate `json:"pools"`
+	Needs    Needs         `json:"needs"`
+	Count    int           `json:"count"`
+	Draw     Draw          `json:"draw"`
+}
+
+// NewInputs describes a selection for the team; rotation is where the team's
+// round robin stands.
+func NewInputs(settings models.TeamSettings, pools [][]Candidate, needs Needs, count int, rotation string) Inputs {
+	return Inputs{
+		Team:     settings.TeamName,
+		Strategy: settings.AssignmentStrategy,
+		Pools:    pools,
+		Needs:    needs,
+		Count:    count,
+		Draw:     Draw{Rotation: rotation},
+	}
+}
+
+// Record describes the selection for the assignment log of a PR. rotation is
+// where the selection left the team's round robin; the team's next selection
+// continues from it.
+func (in Inputs) Record(prID, assignedBy string, reviewers []string, rotation string) (models.AssignmentRecord, error) {
+	inputs, err := json.Marshal(in)
+	if err != nil {
+		return models.AssignmentRecord{}, err
+	}
+	return models.AssignmentRecord{
+		PullRequestID: prID,
+		TeamName:      in.Team,
+		AssignedBy:    assignedBy,
+		Strategy:      in.Strategy,
+		Seed:          in.Draw.Seed,
+		Inputs:        inputs,
+		Reviewers:     append([]string{}, reviewers...),
+		Rotation:      rotation,
+	}, nil
+}
+
+// Result is the outcome of one selection.
+type Result struct {
+	Reviewers []string                 `json:"reviewers"`
+	Skipped   []models.SkippedReviewer `json:"skipped_reviewers"`
+	// UncoveredTags are the needed tags no reviewer holds.
+	UncoveredTags []string `json:"uncovered_tags"`
+	// RoleMet is false when a role was needed and no reviewer has it.
+	RoleMet bool `json:"role_met"`
+	// Rotation is where the team's round robin stands after the selection.
+	Rotation string `json:"-"`
+}
+
+// Assigner runs selections. Each selection is seeded from the assigner's own
+// source, so an Assigner built with a fixed seed picks the same reviewers run
+// after run, and any single selection can be replayed from its Inputs. The
+// service builds one in main and hands it to everything that assigns.
+type Assigner struct {
+	mu    sync.Mutex
+	seeds *rand.Rand
+	// next is the seed the next selection runs with, drawn ahead so Preview
+	// can use it too.
+	next int64
+}
+
+func NewAssigner(seed int64) *Assigner {
+	seeds := rand.New(rand.NewSource(seed))
+	return &Assigner{
+		seeds: seeds,
+		next:  seeds.Int63(),
+	}
+}
+
+// Run seeds in, runs the selection and moves the seed on. It returns the
+// inputs with their draw, for replaying.
+func (a *Assigner) Run(in Inputs) (Inputs, Result) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	in.Draw.Seed = a.next
+	result := run(in)
+	a.next = a.seeds.Int63()
+	return in, result
+}
+
+// Preview runs the selection as Run would next, but leaves the assigner as
+// it is, so the selection after it is the same as without it.
+func (a *Assigner) Preview(in Inputs) (Inputs, Result) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	in.Draw.Seed = a.next
+	return in, run(in)
+}
+
+// Replay runs the selection in describes again, leaving every Assigner
+// alone.
+func Replay(in Inputs) Result {
+	return run(in)
+}
+
+// run first makes sure one reviewer has the needed role and each needed tag
+// is held by a reviewer, as far as the eligible candidates and count allow,
+// then fills the remaining places with the team's strategy.
+func run(in Inputs) Result {
+	source := &Source{Rand: rand.New(rand.NewSource(in.Draw.Seed)), Rotation: in.Draw.Rotation}
+
+	covering, uncovered, roleMet := cover(in.Pools, in.Needs, in.Count)
+
+	rest := make([][]Candidate, len(in.Pools))
+	for i, pool := range in.Pools {
+		for _, candidate := range pool {
+			if !contains(covering, candidate.UserID) {
+				rest[i] = append(rest[i], candidate)
+			}
+		}
+	}
+
+	reviewers, skipped := pick(ForStrategy(in.Strategy), source, rest, in.Count-len(covering))
+	if skipped == nil {
+		skipped = []models.SkippedReviewer{}
+	}
+	return Result{
+		Reviewers:     append(covering, reviewers...),
+		Skipped:       skipped,
+		UncoveredTags: uncovered,
+		RoleMet:       roleMet,
+		Rotation:      source.Rotation,
+	}
+}
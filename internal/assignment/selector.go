@@ -0,0 +1,165 @@
+package assignment
+
+import (
+	"math/rand"
+	"sort"
+
+	"pr-reviewer-service/internal/models"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+)
+
+type Candidate struct {
+	UserID      string `json:"user_id"`
+	OpenReviews int    `json:"open_reviews"`
+	// MaxOpenReviews is the user's effective limit; nil means unlimited.
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
+	// Away is the availability period the user is in, if any.
+	Away *models.AvailabilityPeriod `json:"away,omitempty"`
+	// Skills maps the user's skill tags to their proficiency.
+	Skills map[string]int `json:"skills,omitempty"`
+	// Role is the user's reviewer role, if any.
+	Role string `json:"role,omitempty"`
+	// Exclusion is the rule barring the user from reviewing the author, if
+	// any.
+	Exclusion *models.ReviewerExclusion `json:"exclusion,omitempty"`
+	// Repeats, when non-zero, is how many of the author's latest PRs in a
+	// row the user reviewed, having reached the team's limit.
+	Repeats int `json:"repeats,omitempty"`
+}
+
+// Source is the randomness and rotation one selection runs with.
+type Source struct {
+	Rand *rand.Rand
+	// Rotation is the user the team's round robin continues after; round
+	// robin moves it on to its last pick.
+	Rotation string
+}
+
+type ReviewerSelector interface {
+	Select(source *Source, candidates []Candidate, count int) []string
+}
+
+func shuffle(source *Source, candidates []Candidate) []Candidate {
+	shuffled := make([]Candidate, len(candidates))
+	copy(shuffled, candidates)
+
+	source.Rand.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return shuffled
+}
+
+func firstIDs(candidates []Candidate, count int) []string {
+	count = min(count, len(candidates))
+	if count <= 0 {
+		return []string{}
+	}
+
+	ids := make([]string, count)
+	for i := 0; i < count; i++ {
+		ids[i] = candidates[i].UserID
+	}
+	return ids
+}
+
+type randomSelector struct{}
+
+func (randomSelector) Select(source *Source, candidates []Candidate, count int) []string {
+	return firstIDs(shuffle(source, candidates), count)
+}
+
+type roundRobinSelector struct{}
+
+func (roundRobinSelector) Select(source *Source, candidates []Candidate, count int) []string {
+	count = min(count, len(candidates))
+	if count <= 0 {
+		return []string{}
+	}
+
+	sorted := make([]Candidate, len(candidates))
+	copy(sorted, candidates)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].UserID < sorted[j].UserID
+	})
+
+	start := sort.Search(len(sorted), func(i int) bool {
+		return sorted[i].UserID > source.Rotation
+	})
+
+	ids := make([]string, count)
+	for i := 0; i < count; i++ {
+		ids[i] = sorted[(start+i)%len(sorted)].UserID
+	}
+	source.Rotation = ids[count-1]
+
+	return ids
+}
+
+type leastLoadedSelector struct{}
+
+func (leastLoadedSelector) Select(source *Source, candidates []Candidate, count int) []string {
+	sorted := shuffle(source, candidates)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].OpenReviews < sorted[j].OpenReviews
+	})
+	return firstIDs(sorted, count)
+}
+
+type weightedSelector struct{}
+
+func (weightedSelector) Select(source *Source, candidates []Candidate, count int) []string {
+	pool := make([]Candidate, len(candidates))
+	copy(pool, candidates)
+
+	ids := []string{}
+	for len(ids) < count && len(pool) > 0 {
+		total := 0.0
+		for _, c := range pool {
+			total += weight(c)
+		}
+
+		target := source.Rand.Float64() * total
+		picked := len(pool) - 1
+		for i, c := range pool {
+			target -= weight(c)
+			if target < 0 {
+				picked = i
+				break
+			}
+		}
+
+		ids = append(ids, pool[picked].UserID)
+		pool = append(pool[:picked], pool[picked+1:]...)
+	}
+	return ids
+}
+
+func weight(c Candidate) float64 {
+	return 1 / float64(1+c.OpenReviews)
+}
+
+var selectors = map[string]ReviewerSelector{
+	StrategyRandom:      randomSelector{},
+	StrategyRoundRobin:  roundRobinSelector{},
+	StrategyLeastLoaded: leastLoadedSelector{},
+	StrategyWeighted:    weightedSelector{},
+}
+
+func IsValidStrategy(name string) bool {
+	_, ok := selectors[name]
+	return ok
+}
+
+func ForStrategy(name string) ReviewerSelector {
+	if selector, ok := selectors[name]; ok {
+		return selector
+	}
+	return selectors[StrategyRandom]
+}
@@ -0,0 +1,278 @@
+package assignment
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"pr-reviewer-service/internal/codeowners"
+	"pr-reviewer-service/internal/models"
+)
+
+const (
+	DefaultMinReviewers = 0
+	DefaultMaxReviewers = 2
+	MaxReviewersLimit   = 10
+)
+
+func DefaultSettings(teamName string) models.TeamSettings {
+	return models.TeamSettings{
+		TeamName:           teamName,
+		MinReviewers:       DefaultMinReviewers,
+		MaxReviewers:       DefaultMaxReviewers,
+		AssignmentStrategy: StrategyRandom,
+		FallbackTeams:      []string{},
+		OwnershipRules:     []models.OwnershipRule{},
+	}
+}
+
+func ValidateSettings(settings models.TeamSettings) error {
+	if settings.MinReviewers < 0 {
+		return errors.New("min_reviewers must not be negative")
+	}
+	if settings.MaxReviewers < 1 || settings.MaxReviewers > MaxReviewersLimit {
+		return fmt.Errorf("max_reviewers must be between 1 and %d", MaxReviewersLimit)
+	}
+	if settings.MinReviewers > settings.MaxReviewers {
+		return errors.New("min_reviewers must not exceed max_reviewers")
+	}
+	if settings.RequiredApprovals < 0 || settings.RequiredApprovals > settings.MaxReviewers {
+		return errors.New("required_approvals must be between 0 and max_reviewers")
+	}
+	if settings.MaxOpenReviews < 0 {
+		return errors.New("max_open_reviews must not be negative")
+	}
+	if !IsValidStrategy(settings.AssignmentStrategy) {
+		return errors.New("unknown assignment_strategy")
+	}
+	if settings.MinReviewerRole != "" && !models.IsValidRole(settings.MinReviewerRole) {
+		return errors.New("min_reviewer_role must be junior, mid, senior or maintainer")
+	}
+	if settings.MaxConsecutiveReviews < 0 {
+		return errors.New("max_consecutive_reviews must not be negative")
+	}
+	seen := make(map[string]bool)
+	for _, team := range settings.FallbackTeams {
+		if team == "" || team == settings.TeamName {
+			return errors.New("fallback_teams must name other teams")
+		}
+		if seen[team] {
+			return fmt.Errorf("fallback team %s is listed twice", team)
+		}
+		seen[team] = true
+	}
+	for _, rule := range settings.OwnershipRules {
+		if err := codeowners.Validate(rule.Pattern); err != nil {
+			return fmt.Errorf("ownership_rules: %v", err)
+		}
+	}
+	return nil
+}
+
+// Eligible keeps the candidates that can take another review and reports
+// the rest as skipped: those excluded from reviewing the author, those away
+// on an availability period, those at their review limit and those who
+// reviewed too many of the author's PRs in a row.
+func Eligible(candidates []Candidate) ([]Candidate, []models.SkippedReviewer) {
+	var available []Candidate
+	var skipped []models.SkippedReviewer
+	for _, candidate := range candidates {
+		switch {
+		case candidate.Exclusion != nil:
+			skipped = append(skipped, models.SkippedReviewer{
+				UserID: candidate.UserID,
+				Reason: models.SkipExcluded,
+				Detail: candidate.Exclusion.Reason,
+			})
+		case candidate.Away != nil:
+			detail := "away until " + candidate.Away.End.Format(time.RFC3339)
+			if candidate.Away.Reason != "" {
+				detail += ": " + candidate.Away.Reason
+			}
+			skipped = append(skipped, models.SkippedReviewer{
+				UserID: candidate.UserID,
+				Reason: models.SkipUnavailable,
+				Detail: detail,
+			})
+		case candidate.MaxOpenReviews != nil && candidate.OpenReviews >= *candidate.MaxOpenReviews:
+			skipped = append(skipped, models.SkippedReviewer{
+				UserID: candidate.UserID,
+				Reason: models.SkipAtCapacity,
+				Detail: fmt.Sprintf("%d open reviews, limit %d", candidate.OpenReviews, *candidate.MaxOpenReviews),
+			})
+		case candidate.Repeats > 0:
+			skipped = append(skipped, models.SkippedReviewer{
+				UserID: candidate.UserID,
+				Reason: models.SkipRecentReviewer,
+				Detail: fmt.Sprintf("reviewed the author's last %d PRs", candidate.Repeats),
+			})
+		default:
+			available = append(available, candidate)
+		}
+	}
+	return available, skipped
+}
+
+// pick selects count reviewers from the first pool and, while that is not
+// enough, from each following pool in order. A user in several pools is
+// considered once. Candidates that are not Eligible are passed over and
+// reported as skipped.
+func pick(selector ReviewerSelector, source *Source, pools [][]Candidate, count int) ([]string, []models.SkippedReviewer) {
+	reviewers := []string{}
+	var skipped []models.SkippedReviewer
+	seen := make(map[string]bool)
+	for i, pool := range pools {
+		if i > 0 && len(reviewers) >= count {
+			break
+		}
+
+		var fresh []Candidate
+		for _, candidate := range pool {
+			if !seen[candidate.UserID] {
+				seen[candidate.UserID] = true
+				fresh = append(fresh, candidate)
+			}
+		}
+
+		available, passedOver := Eligible(fresh)
+		skipped = append(skipped, passedOver...)
+		reviewers = append(reviewers, selector.Select(source, available, count-len(reviewers))...)
+	}
+	return reviewers, skipped
+}
+
+// Needs are what the chosen reviewers must bring between them.
+type Needs struct {
+	// Tags are skill tags each to be held by at least one reviewer.
+	Tags []string
+	// Role, when set, is the least senior role at least one reviewer must
+	// have.
+	Role string
+}
+
+func (c Candidate) hasRole(role string) bool {
+	return models.HasRoleAtLeast(c.Role, role)
+}
+
+// Score rates how well a candidate fits a selection: a point for the needed
+// role and for each needed tag they hold, plus up to one more the fewer open
+// reviews they have.
+func Score(candidate Candidate, needs Needs) float64 {
+	score := weight(candidate)
+	if needs.Role != "" && candidate.hasRole(needs.Role) {
+		score++
+	}
+	for _, tag := range needs.Tags {
+		if _, ok := candidate.Skills[tag]; ok {
+			score++
+		}
+	}
+	return score
+}
+
+// cover picks reviewers for the needs greedily. The first pick has the role,
+// if one is needed; after that whoever holds the most missing tags goes
+// first, then the higher summed proficiency, then the earlier pool.
+func cover(pools [][]Candidate, needs Needs, count int) ([]string, []string, bool) {
+	var candidates []Candidate
+	seen := make(map[string]bool)
+	for _, pool := range pools {
+		for _, candidate := range pool {
+			if !seen[candidate.UserID] {
+				seen[candidate.UserID] = true
+				candidates = append(candidates, candidate)
+			}
+		}
+	}
+	candidates, _ = Eligible(candidates)
+
+	missing := append([]string{}, needs.Tags...)
+	picked := []string{}
+	pick := func(chosen Candidate) {
+		picked = append(picked, chosen.UserID)
+		var still []string
+		for _, tag := range missing {
+			if _, ok := chosen.Skills[tag]; !ok {
+				still = append(still, tag)
+			}
+		}
+		missing = still
+	}
+
+	roleMet := needs.Role == ""
+	if !roleMet && count > 0 {
+		if best := bestCover(candidates, picked, missing, needs.Role); best >= 0 {
+			pick(candidates[best])
+			roleMet = true
+		}
+	}
+	for len(picked) < count && len(missing) > 0 {
+		best := bestCover(candidates, picked, missing, "")
+		if best < 0 {
+			break
+		}
+		pick(candidates[best])
+	}
+
+	if missing == nil {
+		missing = []string{}
+	}
+	return picked, missing, roleMet
+}
+
+// bestCover finds the candidate not yet picked who holds the most missing
+// tags, breaking ties by summed proficiency. Without a role only candidates
+// holding a missing tag qualify; with one, any candidate who has the role.
+func bestCover(candidates []Candidate, picked, missing []string, role string) int {
+	best, bestCovered, bestLevel := -1, 0, 0
+	for i, candidate := range candidates {
+		if contains(picked, candidate.UserID) || (role != "" && !candidate.hasRole(role)) {
+			continue
+		}
+		covered, level := 0, 0
+		for _, tag := range missing {
+			if proficiency, ok := candidate.Skills[tag]; ok {
+				covered++
+				level += proficiency
+			}
+		}
+		if role == "" && covered == 0 {
+			continue
+		}
+		if best < 0 || covered > bestCovered || (covered == bestCovered && level > bestLevel) {
+			best, bestCovered, bestLevel = i, covered, level
+		}
+	}
+	return best
+}
+
+// Covers reports whether the eligible candidates in pools between them have
+// the needed role and hold every needed tag.
+func Covers(pools [][]Candidate, needs Needs) bool {
+	held := make(map[string]bool)
+	roleMet := needs.Role == ""
+	for _, pool := range pools {
+		available, _ := Eligible(pool)
+		for _, candidate := range available {
+			for tag := range candidate.Skills {
+				held[tag] = true
+			}
+			roleMet = roleMet || candidate.hasRole(needs.Role)
+		}
+	}
+	for _, tag := range needs.Tags {
+		if !held[tag] {
+			return false
+		}
+	}
+	return roleMet
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
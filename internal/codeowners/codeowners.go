@@ -0,0 +1,150 @@
+// Package codeowners parses CODEOWNERS files and matches changed paths
+// against ownership rules.
+//
+// Patterns follow the CODEOWNERS flavour of gitignore: a leading or inner
+// slash anchors a pattern to the repository root, a trailing slash matches
+// everything under a directory, "*" and "?" stay within one path segment and
+// "**" spans any number of them. A pattern whose last segment is literal also
+// matches everything under a directory of that name, while "docs/*" matches
+// only the files directly in docs. When several rules match a path, the last
+// one wins.
+package codeowners
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+)
+
+// Parse reads rules in the CODEOWNERS format. Owners are stored without the
+// leading "@"; a rule may have no owners, which clears ownership of the paths
+// it matches.
+func Parse(r io.Reader) ([]models.OwnershipRule, error) {
+	rules := []models.OwnershipRule{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		pattern := strings.TrimPrefix(fields[0], `\`)
+		if err := Validate(pattern); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		owners := []string{}
+		for _, owner := range fields[1:] {
+			if strings.HasPrefix(owner, "#") {
+				break
+			}
+			owners = append(owners, strings.TrimPrefix(owner, "@"))
+		}
+		rules = append(rules, models.OwnershipRule{Pattern: pattern, Owners: owners})
+	}
+
+	return rules, scanner.Err()
+}
+
+// Validate reports whether pattern can be used in an ownership rule.
+func Validate(pattern string) error {
+	_, err := compile(pattern)
+	return err
+}
+
+// Owners returns the owners of the given paths, in the order they are first
+// seen, each path taking the owners of the last rule that matches it.
+func Owners(rules []models.OwnershipRule, paths []string) []string {
+	matchers := make([]*regexp.Regexp, len(rules))
+	for i, rule := range rules {
+		matchers[i], _ = compile(rule.Pattern)
+	}
+
+	var owners []string
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		path = strings.TrimPrefix(path, "/")
+		for i := len(rules) - 1; i >= 0; i-- {
+			if matchers[i] == nil || !matchers[i].MatchString(path) {
+				continue
+			}
+			for _, owner := range rules[i].Owners {
+				if !seen[owner] {
+					seen[owner] = true
+					owners = append(owners, owner)
+				}
+			}
+			break
+		}
+	}
+	return owners
+}
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return nil, errors.New("pattern must not be empty")
+	}
+
+	anchored := strings.HasPrefix(pattern, "/") || strings.Contains(trimmed, "/")
+	dirOnly := strings.HasSuffix(pattern, "/")
+	last := trimmed[strings.LastIndex(trimmed, "/")+1:]
+	literal := !strings.ContainsAny(last, "*?[")
+
+	var expr strings.Builder
+	if anchored {
+		expr.WriteString("^")
+	} else {
+		expr.WriteString("^(?:.*/)?")
+	}
+
+	for i := 0; i < len(trimmed); i++ {
+		switch c := trimmed[i]; {
+		case strings.HasPrefix(trimmed[i:], "**/"):
+			expr.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(trimmed[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(trimmed[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class in %q", pattern)
+			}
+			class := trimmed[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i += end
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		expr.WriteString("/.*$")
+	case literal:
+		expr.WriteString("(?:/.*)?$")
+	default:
+		expr.WriteString("$")
+	}
+
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	return re, nil
+}
@@ -1,6 +1,10 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"time"
+)
 
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -9,10 +13,49 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value, err := strconv.ParseInt(os.Getenv(key), 10, 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 var (
 	Host     = getEnv("DB_HOST", "localhost")
 	Port     = 5432
 	User     = getEnv("DB_USER", "postgres")
 	Password = getEnv("DB_PASSWORD", "password")
 	DBName   = getEnv("DB_NAME", "pr_reviewer")
+
+	StorageBackend = getEnv("STORAGE_BACKEND", "postgres")
+	MigrateOnStart = getEnv("MIGRATE_ON_START", "true") == "true"
+
+	// QueryTimeout bounds every storage call; zero leaves only the caller's
+	// deadline in effect.
+	QueryTimeout = getEnvDuration("DB_QUERY_TIMEOUT", 3*time.Second)
+
+	// IdempotencyTTL is how long a response stays available for replay to
+	// retries carrying the same Idempotency-Key.
+	IdempotencyTTL = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+
+	// ReviewHandoffInterval is how often open reviews of users whose
+	// availability period has started are checked for reassignment.
+	ReviewHandoffInterval = getEnvDuration("REVIEW_HANDOFF_INTERVAL", time.Minute)
+
+	// AssignmentSeed seeds reviewer selection so a run can be reproduced;
+	// zero seeds it from the clock.
+	AssignmentSeed = getEnvInt64("ASSIGNMENT_SEED", 0)
+
+	GitHubWebhookSecret = getEnv("GITHUB_WEBHOOK_SECRET", "")
+	GitLabWebhookSecret = getEnv("GITLAB_WEBHOOK_SECRET", "")
+	GiteaWebhookSecret  = getEnv("GITEA_WEBHOOK_SECRET", "")
+
+	WebhookDeliveryWorkers = 4
 )
@@ -0,0 +1,252 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+type SubscriptionStore interface {
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	AddDeadLetter(ctx context.Context, letter models.DeadLetter) error
+	EventDeliveries(ctx context.Context, eventID string) ([]string, error)
+	MarkEventDelivered(ctx context.Context, eventID, sink string) error
+}
+
+// subscriptionSink is the outbox delivery name recording that an event was
+// delivered or dead-lettered for a subscription.
+func subscriptionSink(subscriptionID int64) string {
+	return fmt.Sprintf("webhook:%d", subscriptionID)
+}
+
+type delivery struct {
+	subscription models.WebhookSubscription
+	event        Event
+	body         []byte
+}
+
+// Dispatcher delivers events to webhook subscribers from a pool of
+// background workers. Failed deliveries are retried with exponential
+// backoff and end up in the dead-letter table once MaxAttempts is reached.
+// An event counts as published to the dispatcher only once every
+// subscription it is for got it or has a dead letter, so deliveries lost to a
+// full queue or a restart are sent again.
+type Dispatcher struct {
+	store  SubscriptionStore
+	client *http.Client
+
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+
+	jobs chan delivery
+	stop chan struct{}
+	wg   sync.WaitGroup
+
+	mu sync.Mutex
+	// queued holds the deliveries queued or in progress, by event and
+	// subscription, so later passes of the relay do not queue them twice.
+	queued map[string]bool
+}
+
+func NewDispatcher(store SubscriptionStore) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		MaxAttempts: 5,
+		BaseBackoff: time.Second,
+		MaxBackoff:  time.Minute,
+		jobs:        make(chan delivery, 1000),
+		stop:        make(chan struct{}),
+		queued:      make(map[string]bool),
+	}
+}
+
+func (d *Dispatcher) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+// Stop waits for in-flight attempts to finish. Queued deliveries and pending
+// retries are dropped; their events stay in the outbox and are delivered
+// after a restart.
+func (d *Dispatcher) Stop() {
+	close(d.stop)
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) Name() string {
+	return "webhook"
+}
+
+// Publish queues the event for every matching subscription that has not got
+// it yet and returns ErrDeliveryPending until none is left. A delivery that
+// does not fit in the queue waits for the next call.
+func (d *Dispatcher) Publish(event Event) error {
+	ctx := context.Background()
+	subs, err := d.store.ListWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	delivered, err := d.store.EventDeliveries(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	pending := false
+	for _, sub := range subs {
+		if !sub.Wants(event.Type) || contains(delivered, subscriptionSink(sub.ID)) {
+			continue
+		}
+		pending = true
+
+		job := delivery{subscription: sub, event: event, body: body}
+		if d.queued[job.key()] {
+			continue
+		}
+		select {
+		case d.jobs <- job:
+			d.queued[job.key()] = true
+		default:
+		}
+	}
+
+	if pending {
+		return ErrDeliveryPending
+	}
+	return nil
+}
+
+func (job delivery) key() string {
+	return job.event.ID + "/" + subscriptionSink(job.subscription.ID)
+}
+
+// done records that the delivery needs no more attempts.
+func (d *Dispatcher) done(job delivery) {
+	if err := d.store.MarkEventDelivered(context.Background(), job.event.ID, subscriptionSink(job.subscription.ID)); err != nil {
+		log.Printf("Error recording delivery of event %s: %v", job.event.ID, err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.queued, job.key())
+}
+
+func (d *Dispatcher) work() {
+	defer d.wg.Done()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case job := <-d.jobs:
+			d.deliver(job)
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(job delivery) {
+	var lastErr error
+	for attempt := 1; attempt <= d.MaxAttempts; attempt++ {
+		lastErr = d.send(job)
+		if lastErr == nil {
+			d.done(job)
+			return
+		}
+		if attempt == d.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-d.stop:
+			return
+		case <-time.After(d.backoff(attempt)):
+		}
+	}
+
+	if d.deadLetter(job, d.MaxAttempts, lastErr) {
+		d.done(job)
+	}
+}
+
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.BaseBackoff << (attempt - 1)
+	if delay <= 0 || delay > d.MaxBackoff {
+		return d.MaxBackoff
+	}
+	return delay
+}
+
+func (d *Dispatcher) send(job delivery) error {
+	req, err := http.NewRequest("POST", job.subscription.URL, bytes.NewReader(job.body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", job.event.Type)
+	req.Header.Set("X-Webhook-Delivery", job.event.ID)
+	if job.subscription.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", Sign(job.subscription.Secret, job.body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// deadLetter reports whether the dead letter was stored. If it was not, the
+// delivery is left pending and tried again.
+func (d *Dispatcher) deadLetter(job delivery, attempts int, cause error) bool {
+	err := d.store.AddDeadLetter(context.Background(), models.DeadLetter{
+		SubscriptionID: job.subscription.ID,
+		EventID:        job.event.ID,
+		EventType:      job.event.Type,
+		Payload:        string(job.body),
+		Attempts:       attempts,
+		LastError:      cause.Error(),
+	})
+	if err != nil {
+		log.Printf("Error storing dead letter for event %s: %v", job.event.ID, err)
+
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		delete(d.queued, job.key())
+		return false
+	}
+	return true
+}
+
+// Sign returns the X-Webhook-Signature value receivers should expect for body.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,63 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+const (
+	TypePRCreated        = "pr.created"
+	TypeReviewerAssigned = "reviewer.assigned"
+	TypeReviewerReplaced = "reviewer.replaced"
+	TypePRMerged         = "pr.merged"
+	TypeTeamDeactivated  = "team.deactivated"
+)
+
+var knownTypes = map[string]bool{
+	TypePRCreated:        true,
+	TypeReviewerAssigned: true,
+	TypeReviewerReplaced: true,
+	TypePRMerged:         true,
+	TypeTeamDeactivated:  true,
+}
+
+func IsKnownType(eventType string) bool {
+	return knownTypes[eventType]
+}
+
+type Event struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// PendingEvent is an outbox entry that has not reached every sink yet.
+// DeliveredTo lists the sinks that got it, and for the webhook sink also each
+// subscription it was delivered or dead-lettered for.
+type PendingEvent struct {
+	Event       Event
+	DeliveredTo []string
+}
+
+func New(eventType string, data interface{}) Event {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		payload = []byte("null")
+	}
+
+	return Event{
+		ID:         newID(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Data:       payload,
+	}
+}
+
+func newID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,121 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+type OutboxStore interface {
+	// PendingEvents claims up to limit unpublished events for owner until
+	// the lease runs out. Events claimed by another owner whose lease has not
+	// run out are left alone; owner's own claims are renewed.
+	PendingEvents(ctx context.Context, owner string, limit int, lease time.Duration) ([]PendingEvent, error)
+	MarkEventDelivered(ctx context.Context, eventID, sink string) error
+	MarkEventPublished(ctx context.Context, eventID string) error
+}
+
+// Relay moves events from the outbox to the sinks. Each sink's delivery is
+// recorded separately, so a failing sink is retried on the next pass without
+// resending the event to sinks that already accepted it. Events are claimed
+// for Lease, so relays of several replicas do not publish the same event.
+type Relay struct {
+	store OutboxStore
+	sinks []Sink
+	owner string
+
+	Interval  time.Duration
+	BatchSize int
+	// Lease should outlast a background delivery with all its retries.
+	Lease time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewRelay(store OutboxStore, sinks ...Sink) *Relay {
+	return &Relay{
+		store:     store,
+		sinks:     sinks,
+		owner:     newID(),
+		Interval:  time.Second,
+		BatchSize: 100,
+		Lease:     5 * time.Minute,
+		stop:      make(chan struct{}),
+	}
+}
+
+func (r *Relay) Start() {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		ticker := time.NewTicker(r.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.RunOnce(context.Background()); err != nil {
+				log.Printf("Error relaying outbox events: %v", err)
+			}
+
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *Relay) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// RunOnce relays one batch of pending events.
+func (r *Relay) RunOnce(ctx context.Context) error {
+	pending, err := r.store.PendingEvents(ctx, r.owner, r.BatchSize, r.Lease)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range pending {
+		published := true
+		for _, sink := range r.sinks {
+			if contains(entry.DeliveredTo, sink.Name()) {
+				continue
+			}
+
+			if err := sink.Publish(entry.Event); errors.Is(err, ErrDeliveryPending) {
+				published = false
+				continue
+			} else if err != nil {
+				log.Printf("Error publishing event %s to %s: %v", entry.Event.ID, sink.Name(), err)
+				published = false
+				continue
+			}
+			if err := r.store.MarkEventDelivered(ctx, entry.Event.ID, sink.Name()); err != nil {
+				return err
+			}
+		}
+
+		if published {
+			if err := r.store.MarkEventPublished(ctx, entry.Event.ID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+// ErrDeliveryPending is returned by a sink that took the event but delivers
+// it in the background. The event is offered to it again on later passes
+// until it returns nil.
+var ErrDeliveryPending = errors.New("delivery pending")
+
+// Sink receives events relayed from the outbox. Name must be stable: it is
+// persisted to remember which sinks already got an event.
+type Sink interface {
+	Name() string
+	Publish(event Event) error
+}
+
+type LogSink struct{}
+
+func (LogSink) Name() string {
+	return "log"
+}
+
+func (LogSink) Publish(event Event) error {
+	log.Printf("Event %s %s: %s", event.ID, event.Type, event.Data)
+	return nil
+}
+
+// MessagePublisher is the subset of a NATS-style client the broker sink
+// needs; *nats.Conn satisfies it.
+type MessagePublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// BrokerSink publishes every event to SubjectPrefix + event type, e.g.
+// "pr-reviewer.pr.created".
+type BrokerSink struct {
+	Conn          MessagePublisher
+	SubjectPrefix string
+}
+
+func (s BrokerSink) Name() string {
+	return "broker"
+}
+
+func (s BrokerSink) Publish(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.Conn.Publish(s.SubjectPrefix+event.Type, body)
+}
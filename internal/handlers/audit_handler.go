@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"strconv"
+	"time"
+)
+
+// withActor attributes the request's changes in the audit log to the caller
+// named in the X-Actor header.
+func withActor(r *http.Request, store storage.Store) storage.Store {
+	actor := r.Header.Get("X-Actor")
+	if actor == "" {
+		actor = "api"
+	}
+	return store.WithActor(actor)
+}
+
+func AuditHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.AuditFilter{
+		PullRequestID: query.Get("pull_request_id"),
+		UserID:        query.Get("user_id"),
+		TeamName:      query.Get("team_name"),
+		Action:        query.Get("action"),
+	}
+
+	var err error
+	if from := query.Get("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			SendError(w, ErrorNotFound, "from must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+	if to := query.Get("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			SendError(w, ErrorNotFound, "to must be an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if filter.AfterID, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			SendError(w, ErrorNotFound, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			SendError(w, ErrorNotFound, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	auditEvents, err := store.ListAuditEvents(r.Context(), filter)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to list audit events"))
+		return
+	}
+
+	// A full page may have more events behind it; the client stops once
+	// next_cursor comes back empty.
+	nextCursor := ""
+	if len(auditEvents) > 0 && (filter.Limit == 0 || len(auditEvents) == filter.Limit) {
+		nextCursor = strconv.FormatInt(auditEvents[len(auditEvents)-1].ID, 10)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"events":      auditEvents,
+		"next_cursor": nextCursor,
+	})
+}
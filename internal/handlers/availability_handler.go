@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"time"
+)
+
+type availabilityRequest struct {
+	ID              int64     `json:"id"`
+	UserID          string    `json:"user_id"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Reason          string    `json:"reason"`
+	ReassignReviews bool      `json:"reassign_reviews"`
+}
+
+func (r availabilityRequest) period() (models.AvailabilityPeriod, error) {
+	if r.Start.IsZero() || r.End.IsZero() {
+		return models.AvailabilityPeriod{}, newAPIError(ErrorInvalidAvailability, "start and end are required", http.StatusBadRequest)
+	}
+	if !r.End.After(r.Start) {
+		return models.AvailabilityPeriod{}, newAPIError(ErrorInvalidAvailability, "end must be after start", http.StatusBadRequest)
+	}
+	return models.AvailabilityPeriod{
+		ID:              r.ID,
+		UserID:          r.UserID,
+		Start:           r.Start,
+		End:             r.End,
+		Reason:          r.Reason,
+		ReassignReviews: r.ReassignReviews,
+	}, nil
+}
+
+func decodeAvailability(w http.ResponseWriter, r *http.Request) (models.AvailabilityPeriod, bool) {
+	var request availabilityRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorInvalidAvailability, "Invalid JSON; start and end are RFC 3339 timestamps", http.StatusBadRequest)
+		return models.AvailabilityPeriod{}, false
+	}
+
+	period, err := request.period()
+	if err != nil {
+		sendAPIError(w, err)
+		return models.AvailabilityPeriod{}, false
+	}
+	return period, true
+}
+
+func AddAvailabilityHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	period, ok := decodeAvailability(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := store.GetUserByID(r.Context(), period.UserID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get user"))
+		return
+	}
+	if user == nil {
+		SendError(w, ErrorNotFound, "User not found", http.StatusNotFound)
+		return
+	}
+
+	created, err := store.AddAvailabilityPeriod(r.Context(), period)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to add availability period"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period": created,
+	})
+}
+
+func ListAvailabilityHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		SendError(w, ErrorNotFound, "user_id is required", http.StatusBadRequest)
+		return
+	}
+
+	periods, err := store.ListAvailabilityPeriods(r.Context(), userID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to list availability periods"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user_id": userID,
+		"periods": periods,
+	})
+}
+
+func UpdateAvailabilityHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	period, ok := decodeAvailability(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := store.UpdateAvailabilityPeriod(r.Context(), period)
+	if err == sql.ErrNoRows {
+		SendError(w, ErrorNotFound, "availability period not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to update availability period"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period": updated,
+	})
+}
+
+func DeleteAvailabilityHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	err := store.DeleteAvailabilityPeriod(r.Context(), request.ID)
+	if err == sql.ErrNoRows {
+		SendError(w, ErrorNotFound, "availability period not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to delete availability period"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted": request.ID,
+	})
+}
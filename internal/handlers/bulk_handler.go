@@ -3,15 +3,18 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"pr-reviewer-service/internal/assignment"
 	"pr-reviewer-service/internal/storage"
 )
 
-func BulkDeactivateHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func BulkDeactivateHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
 	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	store = withActor(r, store)
+
 	var request struct {
 		TeamName string `json:"team_name"`
 	}
@@ -21,9 +24,9 @@ func BulkDeactivateHandler(w http.ResponseWriter, r *http.Request, store *storag
 		return
 	}
 
-	result, err := store.BulkDeactivateTeamUsers(request.TeamName)
+	result, err := bulkDeactivate(r.Context(), store, assigner, request.TeamName)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to deactivate users", http.StatusInternalServerError)
+		sendAPIError(w, err)
 		return
 	}
 
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+// bulkDeactivate deactivates a team and replaces its members on every open PR
+// they review, in one transaction. Replacements are picked like any other
+// reviewer, so the rules of the PR author's team apply; a PR whose role rule
+// cannot be met loses the deactivated reviewers without replacement and is
+// reported under rejected_prs.
+func bulkDeactivate(ctx context.Context, store storage.Store, assigner *assignment.Assigner, teamName string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	err := inTx(ctx, store, "Failed to deactivate users", func(tx storage.Store) error {
+		deactivated, affectedPRs, err := tx.DeactivateTeamUsers(ctx, teamName)
+		if err != nil {
+			return storeError(err, "Failed to deactivate users")
+		}
+
+		reassigned := 0
+		skippedByPR := make(map[string][]models.SkippedReviewer)
+		rejected := make(map[string]*ErrorResponse)
+		for _, prID := range affectedPRs {
+			replaced, err := replaceDeactivated(ctx, tx, assigner, prID)
+			if err != nil {
+				return err
+			}
+			if replaced.done {
+				reassigned++
+			}
+			if len(replaced.skipped) > 0 {
+				skippedByPR[prID] = replaced.skipped
+			}
+			if replaced.rejection != nil {
+				rejected[prID] = errorResponse(replaced.rejection)
+			}
+		}
+
+		result["deactivated_users"] = int64(len(deactivated))
+		result["affected_prs"] = affectedPRs
+		result["reassigned_prs_count"] = reassigned
+		result["skipped_reviewers"] = skippedByPR
+		result["rejected_prs"] = rejected
+
+		if err := tx.RecordTeamDeactivated(ctx, teamName, deactivated, result); err != nil {
+			return storeError(err, "Failed to deactivate users")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// replacement is what replaceDeactivated did to a PR. rejection is set if the
+// role rule could not be met.
+type replacement struct {
+	done      bool
+	skipped   []models.SkippedReviewer
+	rejection *apiError
+}
+
+// replaceDeactivated replaces the inactive reviewers of a PR. The
+// replacements bring the required tags the staying reviewers lack and, if an
+// inactive reviewer was the one meeting the role rule, someone else who does.
+func replaceDeactivated(ctx context.Context, tx storage.Store, assigner *assignment.Assigner, prID string) (replacement, error) {
+	pr, err := lockPR(ctx, tx, prID)
+	if err != nil {
+		return replacement{}, err
+	}
+
+	author, err := tx.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return replacement{}, storeError(err, "Failed to get author")
+	}
+	if author == nil {
+		return replacement{}, newAPIError(ErrorNotFound, "Author not found", http.StatusNotFound)
+	}
+
+	settings, err := teamSettings(ctx, tx, author.TeamName)
+	if err != nil {
+		return replacement{}, storeError(err, "Failed to get team settings")
+	}
+
+	var staying, removed []string
+	roleStays, roleRemoved := false, false
+	for _, reviewerID := range pr.AssignedReviewers {
+		reviewer, err := tx.GetUserByID(ctx, reviewerID)
+		if err != nil {
+			return replacement{}, storeError(err, "Failed to get reviewer")
+		}
+		meetsRule := reviewer != nil && settings.MinReviewerRole != "" &&
+			models.HasRoleAtLeast(reviewer.Role, settings.MinReviewerRole)
+		if reviewer != nil && reviewer.IsActive {
+			staying = append(staying, reviewerID)
+			roleStays = roleStays || meetsRule
+		} else {
+			removed = append(removed, reviewerID)
+			roleRemoved = roleRemoved || meetsRule
+		}
+	}
+	if len(removed) == 0 {
+		return replacement{}, nil
+	}
+
+	tags, err := tagsMissingFrom(ctx, tx, pr.RequiredTags, staying)
+	if err != nil {
+		return replacement{}, storeError(err, "Failed to get reviewers")
+	}
+	needs := assignment.Needs{Tags: tags}
+	count := settings.MaxReviewers - len(staying)
+	if roleRemoved && !roleStays {
+		needs.Role = settings.MinReviewerRole
+		count = max(count, 1)
+	}
+
+	selected := selection{roleMet: true}
+	if count > 0 {
+		selected, err = selectReviewers(ctx, tx, assigner, settings, reviewRequest{
+			prID:         pr.PullRequestID,
+			authorID:     pr.AuthorID,
+			exclude:      pr.AssignedReviewers,
+			changedFiles: pr.ChangedFiles,
+			needs:        needs,
+			count:        count,
+		})
+		if err != nil {
+			return replacement{}, storeError(err, "Failed to select reviewers")
+		}
+	}
+
+	done := replacement{done: true, skipped: selected.skipped}
+	added := selected.reviewers
+	if !selected.roleMet {
+		done.rejection = roleRequired(needs.Role, selected.skipped).(*apiError)
+		added = nil
+	}
+
+	if err := tx.ReplaceDeactivatedReviewers(ctx, prID, removed, added); err != nil {
+		return replacement{}, storeError(err, "Failed to update PR reviewers")
+	}
+	if len(added) > 0 {
+		if err := recordAssignment(ctx, tx, prID, models.AssignedByBulkDeactivate, selected); err != nil {
+			return replacement{}, err
+		}
+	}
+	return done, nil
+}
@@ -1,10 +1,59 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
+// statusClientClosedRequest is the non-standard status proxies log when the
+// client hangs up before the response is written.
+const statusClientClosedRequest = 499
+
+type apiError struct {
+	code       string
+	message    string
+	statusCode int
+}
+
+func (e *apiError) Error() string {
+	return e.message
+}
+
+func newAPIError(code, message string, statusCode int) *apiError {
+	return &apiError{code: code, message: message, statusCode: statusCode}
+}
+
+// errorResponse is the body SendError would send for err.
+func errorResponse(err *apiError) *ErrorResponse {
+	response := &ErrorResponse{}
+	response.Error.Code = err.code
+	response.Error.Message = err.message
+	return response
+}
+
+func sendAPIError(w http.ResponseWriter, err error) {
+	if apiErr, ok := err.(*apiError); ok {
+		SendError(w, apiErr.code, apiErr.message, apiErr.statusCode)
+		return
+	}
+	SendError(w, ErrorNotFound, err.Error(), http.StatusInternalServerError)
+}
+
+// storeError reports a failed storage call. Calls aborted by the client
+// going away or by a deadline get their own codes, so they are not mistaken
+// for server faults.
+func storeError(err error, message string) *apiError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return newAPIError(ErrorRequestCanceled, "request was canceled", statusClientClosedRequest)
+	case errors.Is(err, context.DeadlineExceeded):
+		return newAPIError(ErrorTimeout, "storage did not respond in time", http.StatusGatewayTimeout)
+	}
+	return internalError(message)
+}
+
 type ErrorResponse struct {
 	Error struct {
 		Code    string `json:"code"`
@@ -30,4 +79,28 @@ const (
 	ErrorNotAssigned = "NOT_ASSIGNED"
 	ErrorNoCandidate = "NO_CANDIDATE"
 	ErrorNotFound    = "NOT_FOUND"
+
+	ErrorInvalidSettings     = "INVALID_SETTINGS"
+	ErrorNotEnoughReviewers  = "NOT_ENOUGH_REVIEWERS"
+	ErrorInvalidVerdict      = "INVALID_VERDICT"
+	ErrorApprovalsRequired   = "APPROVALS_REQUIRED"
+	ErrorInvalidTransition   = "INVALID_TRANSITION"
+	ErrorPRNotOpen           = "PR_NOT_OPEN"
+	ErrorInvalidSignature    = "INVALID_SIGNATURE"
+	ErrorUnknownLogin        = "UNKNOWN_LOGIN"
+	ErrorInvalidSubscription = "INVALID_SUBSCRIPTION"
+	ErrorRequestCanceled     = "REQUEST_CANCELED"
+	ErrorTimeout             = "TIMEOUT"
+	ErrorPreconditionFailed  = "PRECONDITION_FAILED"
+	ErrorInvalidAvailability = "INVALID_AVAILABILITY"
+	ErrorInvalidTags         = "INVALID_TAGS"
+	ErrorInvalidRole         = "INVALID_ROLE"
+	ErrorInvalidRule         = "INVALID_RULE"
+	ErrorInvalidPRID         = "INVALID_PR_ID"
+
+	ErrorReviewerRoleRequired = "REVIEWER_ROLE_REQUIRED"
+
+	ErrorInvalidIdempotencyKey = "INVALID_IDEMPOTENCY_KEY"
+	ErrorIdempotencyKeyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrorIdempotencyKeyInUse   = "IDEMPOTENCY_KEY_IN_USE"
 )
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// anyVersion is what a request without If-Match expects: the change applies to
+// whatever version is current.
+const anyVersion int64 = 0
+
+// setETag exposes a PR's or team's version so clients can send it back in
+// If-Match.
+func setETag(w http.ResponseWriter, version int64) {
+	w.Header().Set("ETag", strconv.Quote(strconv.FormatInt(version, 10)))
+}
+
+// ifMatch reads the version the client last saw. Only strong tags issued by
+// setETag can match; "*" or no header matches any version.
+func ifMatch(r *http.Request) (int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return anyVersion, nil
+	}
+
+	unquoted, err := strconv.Unquote(value)
+	if err != nil {
+		return 0, preconditionFailed(fmt.Sprintf("If-Match %s does not match any version", value))
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, preconditionFailed(fmt.Sprintf("If-Match %s does not match any version", value))
+	}
+	return version, nil
+}
+
+// checkVersion must run after the resource is locked, so nothing can change
+// it between the check and the write.
+func checkVersion(expected, current int64) error {
+	if expected != anyVersion && expected != current {
+		return preconditionFailed(fmt.Sprintf("resource is at version %d, If-Match expected %d", current, expected))
+	}
+	return nil
+}
+
+func preconditionFailed(message string) *apiError {
+	return newAPIError(ErrorPreconditionFailed, message, http.StatusPreconditionFailed)
+}
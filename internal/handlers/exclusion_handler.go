@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+func AddExclusionHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request models.ReviewerExclusion
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if request.ReviewerID == "" || request.AuthorID == "" {
+		SendError(w, ErrorInvalidRule, "reviewer_id and author_id are required", http.StatusBadRequest)
+		return
+	}
+	if request.ReviewerID == request.AuthorID {
+		SendError(w, ErrorInvalidRule, "authors never review their own PRs", http.StatusBadRequest)
+		return
+	}
+
+	for _, userID := range []string{request.ReviewerID, request.AuthorID} {
+		user, err := store.GetUserByID(r.Context(), userID)
+		if err != nil {
+			sendAPIError(w, storeError(err, "Failed to get user"))
+			return
+		}
+		if user == nil {
+			SendError(w, ErrorNotFound, "User "+userID+" not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	exclusion, err := store.AddReviewerExclusion(r.Context(), models.ReviewerExclusion{
+		ReviewerID: request.ReviewerID,
+		AuthorID:   request.AuthorID,
+		Reason:     request.Reason,
+	})
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to add exclusion"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"exclusion": exclusion,
+	})
+}
+
+// ListExclusionsHandler lists the exclusions naming user_id as reviewer or
+// author, or all exclusions without it.
+func ListExclusionsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	exclusions, err := store.ListReviewerExclusions(r.Context(), r.URL.Query().Get("user_id"))
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to list exclusions"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"exclusions": exclusions,
+	})
+}
+
+func DeleteExclusionHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		ReviewerID string `json:"reviewer_id"`
+		AuthorID   string `json:"author_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	err := store.DeleteReviewerExclusion(r.Context(), request.ReviewerID, request.AuthorID)
+	if err == sql.ErrNoRows {
+		SendError(w, ErrorNotFound, "exclusion not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to delete exclusion"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted": request,
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+// explanation is a logged selection replayed from its inputs. Reproduced is
+// false if the replay no longer picks the reviewers that were assigned.
+type explanation struct {
+	models.AssignmentRecord
+	Replayed   assignment.Result `json:"replayed"`
+	Reproduced bool              `json:"reproduced"`
+}
+
+// ExplainPRHandler shows why the reviewers of a PR were chosen: every
+// selection made for it, with its seed and inputs, replayed.
+func ExplainPRHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		SendError(w, ErrorNotFound, "pull_request_id is required", http.StatusBadRequest)
+		return
+	}
+
+	pr, err := store.GetPRByID(r.Context(), prID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get PR"))
+		return
+	}
+	if pr == nil {
+		SendError(w, ErrorNotFound, "PR not found", http.StatusNotFound)
+		return
+	}
+
+	records, err := store.ListAssignmentRecords(r.Context(), prID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get assignment records"))
+		return
+	}
+
+	explanations := make([]explanation, 0, len(records))
+	for _, record := range records {
+		var inputs assignment.Inputs
+		if err := json.Unmarshal(record.Inputs, &inputs); err != nil {
+			sendAPIError(w, internalError("Failed to read assignment record"))
+			return
+		}
+
+		replayed := assignment.Replay(inputs)
+		explanations = append(explanations, explanation{
+			AssignmentRecord: record,
+			Replayed:         replayed,
+			Reproduced:       sameReviewers(record.Reviewers, replayed.Reviewers),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr":          pr,
+		"assignments": explanations,
+	})
+}
+
+func sameReviewers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
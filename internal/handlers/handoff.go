@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"sync"
+	"time"
+)
+
+// handoffActor is recorded in the audit log for reassignments made by
+// ReviewHandoff.
+const handoffActor = "availability"
+
+// ReviewHandoff reassigns the open reviews of users whose availability period
+// asks for it, once the period has started. Each period is handed off once;
+// reviews nobody can take over stay with the absent user.
+type ReviewHandoff struct {
+	store    storage.Store
+	assigner *assignment.Assigner
+
+	Interval time.Duration
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewReviewHandoff(store storage.Store, assigner *assignment.Assigner) *ReviewHandoff {
+	return &ReviewHandoff{
+		store:    store.WithActor(handoffActor),
+		assigner: assigner,
+		Interval: time.Minute,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (h *ReviewHandoff) Start() {
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+
+		ticker := time.NewTicker(h.Interval)
+		defer ticker.Stop()
+
+		for {
+			if err := h.RunOnce(context.Background()); err != nil {
+				log.Printf("Error handing off reviews: %v", err)
+			}
+
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *ReviewHandoff) Stop() {
+	close(h.stop)
+	h.wg.Wait()
+}
+
+// RunOnce hands off the reviews of every period that is due now.
+func (h *ReviewHandoff) RunOnce(ctx context.Context) error {
+	now := time.Now()
+	due, err := h.store.DueReviewHandoffs(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, period := range due {
+		if err := h.handOff(ctx, period.UserID); err != nil {
+			return err
+		}
+		if err := h.store.MarkReviewsHandedOff(ctx, period.ID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *ReviewHandoff) handOff(ctx context.Context, userID string) error {
+	prs, err := h.store.GetPRsByReviewer(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, pr := range prs {
+		if !models.IsActiveStatus(pr.Status) {
+			continue
+		}
+
+		_, selected, err := reassignReviewer(ctx, h.store, h.assigner, pr.PullRequestID, userID, anyVersion)
+		if apiErr, ok := err.(*apiError); ok && apiErr.statusCode < http.StatusInternalServerError {
+			log.Printf("Review of %s stays with %s: %s", pr.PullRequestID, userID, apiErr.message)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Review of %s handed from %s to %s", pr.PullRequestID, userID, selected.reviewers[0])
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"pr-reviewer-service/internal/config"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+
+	// idempotencyLease bounds how long a request that never finished, for
+	// example because the server died, keeps its key from being retried.
+	idempotencyLease = time.Minute
+)
+
+// replayedHeaders are the response headers a replay restores.
+var replayedHeaders = []string{"Content-Type", "ETag"}
+
+// Idempotency makes every POST sent with an Idempotency-Key header safe to
+// retry: the first response is stored and replayed to later requests with the
+// same key, and reusing the key for a different request is rejected. Server
+// errors are not stored, so a retry after one runs the request again.
+func Idempotency(store storage.Store, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != "POST" || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			SendError(w, ErrorInvalidIdempotencyKey, "Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			SendError(w, ErrorNotFound, "Failed to read request body", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := requestHash(r, body)
+		existing, err := store.ClaimIdempotencyKey(r.Context(), key, hash, idempotencyLease)
+		if err != nil {
+			sendAPIError(w, storeError(err, "Failed to claim idempotency key"))
+			return
+		}
+		if existing != nil {
+			replay(w, existing, hash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		// The response is already sent, so it must be stored even if the
+		// client has gone away in the meantime.
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status >= http.StatusInternalServerError || recorder.status == statusClientClosedRequest {
+			err = store.ReleaseIdempotencyKey(ctx, key)
+		} else {
+			err = store.SaveIdempotentResponse(ctx, recorder.response(key), config.IdempotencyTTL)
+		}
+		if err != nil {
+			log.Printf("Error storing response for idempotency key %q: %v", key, err)
+		}
+	})
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, existing *models.IdempotentResponse, hash string) {
+	switch {
+	case existing.RequestHash != hash:
+		SendError(w, ErrorIdempotencyKeyReused, "Idempotency-Key was already used for a different request", http.StatusConflict)
+	case existing.StatusCode == 0:
+		SendError(w, ErrorIdempotencyKeyInUse, "a request with this Idempotency-Key is still in progress", http.StatusConflict)
+	default:
+		for name, value := range existing.Headers {
+			w.Header().Set(name, value)
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(existing.StatusCode)
+		w.Write(existing.Body)
+	}
+}
+
+// responseRecorder passes a response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *responseRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseRecorder) response(key string) models.IdempotentResponse {
+	headers := make(map[string]string)
+	for _, name := range replayedHeaders {
+		if value := w.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return models.IdempotentResponse{
+		Key:        key,
+		StatusCode: status,
+		Headers:    headers,
+		Body:       w.body.Bytes(),
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"pr-reviewer-service/internal/codeowners"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"pr-reviewer-service/internal/webhooks"
+	"strings"
+)
+
+var ownerLoginProviders = []string{webhooks.ProviderGitHub, webhooks.ProviderGitLab, webhooks.ProviderGitea}
+
+// fileOwners resolves the owners of the changed files under the team's
+// ownership rules to active users other than the author. An owner is a user
+// id, a forge login or a team name; "org/team" names team.
+func fileOwners(ctx context.Context, store storage.Store, settings models.TeamSettings, authorID string, changedFiles []string) ([]models.User, error) {
+	var users []models.User
+	seen := make(map[string]bool)
+	add := func(user models.User) {
+		if user.IsActive && user.UserID != authorID && !seen[user.UserID] {
+			seen[user.UserID] = true
+			users = append(users, user)
+		}
+	}
+
+	for _, owner := range codeowners.Owners(settings.OwnershipRules, changedFiles) {
+		if i := strings.LastIndex(owner, "/"); i >= 0 {
+			owner = owner[i+1:]
+		} else {
+			user, err := ownerUser(ctx, store, owner)
+			if err != nil {
+				return nil, err
+			}
+			if user != nil {
+				add(*user)
+				continue
+			}
+		}
+
+		members, err := store.GetActiveTeamMembers(ctx, owner, authorID)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			add(member)
+		}
+	}
+	return users, nil
+}
+
+func ownerUser(ctx context.Context, store storage.Store, owner string) (*models.User, error) {
+	user, err := store.GetUserByID(ctx, owner)
+	if err != nil || user != nil {
+		return user, err
+	}
+	for _, provider := range ownerLoginProviders {
+		user, err := store.GetUserByLogin(ctx, provider, owner)
+		if err != nil || user != nil {
+			return user, err
+		}
+	}
+	return nil, nil
+}
@@ -2,25 +2,27 @@ package handlers
 
 import (
 	"encoding/json"
-	"math/rand"
 	"net/http"
+	"pr-reviewer-service/internal/assignment"
 	"pr-reviewer-service/internal/models"
 	"pr-reviewer-service/internal/storage"
-	"time"
 )
 
-var rng = rand.New(rand.NewSource(time.Now().UnixNano()))
-
-func CreatePRHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func CreatePRHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
 	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	store = withActor(r, store)
+
 	var request struct {
-		PullRequestID   string `json:"pull_request_id"`
-		PullRequestName string `json:"pull_request_name"`
-		AuthorID        string `json:"author_id"`
+		PullRequestID   string   `json:"pull_request_id"`
+		PullRequestName string   `json:"pull_request_name"`
+		AuthorID        string   `json:"author_id"`
+		Draft           bool     `json:"draft"`
+		ChangedFiles    []string `json:"changed_files"`
+		RequiredTags    []string `json:"required_tags"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -28,80 +30,36 @@ func CreatePRHandler(w http.ResponseWriter, r *http.Request, store *storage.Stor
 		return
 	}
 
-	existingPR, _ := store.GetPRByID(request.PullRequestID)
-	if existingPR != nil {
-		SendError(w, ErrorPRExists, "PR id already exists", http.StatusConflict)
-		return
-	}
-
-	author, err := store.GetUserByID(request.AuthorID)
-	if err != nil || author == nil {
-		SendError(w, ErrorNotFound, "Author not found", http.StatusNotFound)
-		return
-	}
-
-	teamMembers, err := store.GetActiveTeamMembers(author.TeamName, request.AuthorID)
+	pr, picked, err := createPR(r.Context(), store, assigner, models.PullRequest{
+		PullRequestID:   request.PullRequestID,
+		PullRequestName: request.PullRequestName,
+		AuthorID:        request.AuthorID,
+		ChangedFiles:    request.ChangedFiles,
+		RequiredTags:    request.RequiredTags,
+	}, request.Draft)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to get team members", http.StatusInternalServerError)
-		return
-	}
-
-	reviewers := assignReviewers(teamMembers, 2)
-
-	pr := models.PullRequest{
-		PullRequestID:     request.PullRequestID,
-		PullRequestName:   request.PullRequestName,
-		AuthorID:          request.AuthorID,
-		Status:            "OPEN",
-		AssignedReviewers: reviewers,
-	}
-
-	err = store.CreatePR(pr)
-	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to create PR", http.StatusInternalServerError)
+		sendAPIError(w, err)
 		return
 	}
 
+	setETag(w, pr.Version)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": pr,
-	})
-}
-
-func assignReviewers(teamMembers []models.User, maxReviewers int) []string {
-	if len(teamMembers) == 0 {
-		return []string{}
-	}
-
-	shuffled := make([]models.User, len(teamMembers))
-	copy(shuffled, teamMembers)
-	rng.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		"pr":                pr,
+		"skipped_reviewers": picked.skipped,
+		"uncovered_tags":    picked.uncoveredTags,
 	})
-
-	count := min(len(shuffled), maxReviewers)
-	reviewers := make([]string, count)
-	for i := 0; i < count; i++ {
-		reviewers[i] = shuffled[i].UserID
-	}
-
-	return reviewers
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
-func MergePRHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func MergePRHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	store = withActor(r, store)
+
 	var request struct {
 		PullRequestID string `json:"pull_request_id"`
 	}
@@ -111,40 +69,33 @@ func MergePRHandler(w http.ResponseWriter, r *http.Request, store *storage.Stora
 		return
 	}
 
-	pr, err := store.GetPRByID(request.PullRequestID)
-	if err != nil || pr == nil {
-		SendError(w, ErrorNotFound, "PR not found", http.StatusNotFound)
-		return
-	}
-
-	if pr.Status == "MERGED" {
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"pr": pr,
-		})
+	version, err := ifMatch(r)
+	if err != nil {
+		sendAPIError(w, err)
 		return
 	}
 
-	err = store.UpdatePRStatus(request.PullRequestID, "MERGED")
+	pr, err := mergePR(r.Context(), store, request.PullRequestID, false, version)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to merge PR", http.StatusInternalServerError)
+		sendAPIError(w, err)
 		return
 	}
 
-	updatedPR, _ := store.GetPRByID(request.PullRequestID)
-
+	setETag(w, pr.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": updatedPR,
+		"pr": pr,
 	})
 }
 
-func ReassignReviewerHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func ReassignReviewerHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
 	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	store = withActor(r, store)
+
 	var request struct {
 		PullRequestID string `json:"pull_request_id"`
 		OldUserID     string `json:"old_user_id"`
@@ -155,111 +106,152 @@ func ReassignReviewerHandler(w http.ResponseWriter, r *http.Request, store *stor
 		return
 	}
 
-	pr, err := store.GetPRByID(request.PullRequestID)
-	if err != nil || pr == nil {
-		SendError(w, ErrorNotFound, "PR not found", http.StatusNotFound)
+	version, err := ifMatch(r)
+	if err != nil {
+		sendAPIError(w, err)
 		return
 	}
 
-	if pr.Status == "MERGED" {
-		SendError(w, ErrorPRMerged, "cannot reassign on merged PR", http.StatusConflict)
+	pr, selected, err := reassignReviewer(r.Context(), store, assigner, request.PullRequestID, request.OldUserID, version)
+	if err != nil {
+		sendAPIError(w, err)
 		return
 	}
 
-	isAssigned := false
-	for _, reviewer := range pr.AssignedReviewers {
-		if reviewer == request.OldUserID {
-			isAssigned = true
-			break
-		}
+	setETag(w, pr.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr":                pr,
+		"replaced_by":       selected.reviewers[0],
+		"skipped_reviewers": selected.skipped,
+		"uncovered_tags":    selected.uncoveredTags,
+	})
+}
+
+func GetPRHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
 	}
 
-	if !isAssigned {
-		SendError(w, ErrorNotAssigned, "reviewer is not assigned to this PR", http.StatusConflict)
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		SendError(w, ErrorNotFound, "pull_request_id is required", http.StatusBadRequest)
 		return
 	}
 
-	oldReviewerTeam, err := store.GetUserTeam(request.OldUserID)
+	pr, err := store.GetPRByID(r.Context(), prID)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Old reviewer team not found", http.StatusNotFound)
+		sendAPIError(w, storeError(err, "Failed to get PR"))
+		return
+	}
+	if pr == nil {
+		SendError(w, ErrorNotFound, "PR not found", http.StatusNotFound)
 		return
 	}
 
-	teamMembers, err := store.GetActiveTeamMembers(oldReviewerTeam, pr.AuthorID)
+	history, err := store.GetPRReviewerHistory(r.Context(), prID)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to get team members", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to get reviewer history"))
 		return
 	}
 
-	availableMembers := []models.User{}
-	for _, member := range teamMembers {
-		isCurrentReviewer := false
-		for _, reviewer := range pr.AssignedReviewers {
-			if member.UserID == reviewer {
-				isCurrentReviewer = true
-				break
-			}
-		}
-		if !isCurrentReviewer {
-			availableMembers = append(availableMembers, member)
-		}
-	}
+	setETag(w, pr.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pr":               pr,
+		"reviewer_history": history,
+	})
+}
 
-	if len(availableMembers) == 0 {
-		SendError(w, ErrorNoCandidate, "no active replacement candidate in team", http.StatusConflict)
+func ReviewPRHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	newReviewer := availableMembers[rng.Intn(len(availableMembers))]
+	store = withActor(r, store)
 
-	newReviewers := make([]string, len(pr.AssignedReviewers))
-	for i, reviewer := range pr.AssignedReviewers {
-		if reviewer == request.OldUserID {
-			newReviewers[i] = newReviewer.UserID
-		} else {
-			newReviewers[i] = reviewer
-		}
+	var request struct {
+		PullRequestID string `json:"pull_request_id"`
+		ReviewerID    string `json:"reviewer_id"`
+		Verdict       string `json:"verdict"`
+		Comment       string `json:"comment"`
 	}
 
-	err = store.UpdatePRReviewers(request.PullRequestID, newReviewers)
-	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to update PR reviewers", http.StatusInternalServerError)
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	updatedPR, _ := store.GetPRByID(request.PullRequestID)
+	pr, err := submitReview(r.Context(), store, request.PullRequestID, request.ReviewerID, request.Verdict, request.Comment)
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
 
+	setETag(w, pr.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr":          updatedPR,
-		"replaced_by": newReviewer.UserID,
+		"pr": pr,
 	})
 }
 
-func GetPRHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
-	if r.Method != "GET" {
+func ClosePRHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	// Closing never assigns reviewers.
+	changePRStatus(w, r, store, nil, models.StatusClosed)
+}
+
+func ReopenPRHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	changePRStatus(w, r, store, assigner, models.StatusReopened)
+}
+
+func ReadyForReviewHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	changePRStatus(w, r, store, assigner, models.StatusOpen)
+}
+
+func changePRStatus(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner, status string) {
+	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	prID := r.URL.Query().Get("pull_request_id")
-	if prID == "" {
-		SendError(w, ErrorNotFound, "pull_request_id is required", http.StatusBadRequest)
+	store = withActor(r, store)
+
+	var request struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	pr, err := store.GetPRByID(prID)
+	version, err := ifMatch(r)
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to get PR", http.StatusInternalServerError)
+		sendAPIError(w, err)
 		return
 	}
-	if pr == nil {
-		SendError(w, ErrorNotFound, "PR not found", http.StatusNotFound)
+
+	var pr *models.PullRequest
+	var picked selection
+	if status == models.StatusOpen {
+		pr, picked, err = markReadyForReview(r.Context(), store, assigner, request.PullRequestID, version)
+	} else {
+		pr, picked, err = setPRStatus(r.Context(), store, assigner, request.PullRequestID, status, version)
+	}
+	if err != nil {
+		sendAPIError(w, err)
 		return
 	}
 
+	response := map[string]interface{}{"pr": pr}
+	if picked.skipped != nil {
+		response["skipped_reviewers"] = picked.skipped
+		response["uncovered_tags"] = picked.uncoveredTags
+	}
+
+	setETag(w, pr.Version)
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"pr": pr,
-	})
+	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,718 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"time"
+)
+
+var (
+	errPRNotFound          = newAPIError(ErrorNotFound, "PR not found", http.StatusNotFound)
+	errNotEnoughReviewers  = newAPIError(ErrorNotEnoughReviewers, "not enough active reviewers to satisfy team settings", http.StatusConflict)
+	errConcurrentStatusSet = newAPIError(ErrorInvalidTransition, "PR status changed concurrently", http.StatusConflict)
+	errPRExists            = newAPIError(ErrorPRExists, "PR id already exists", http.StatusConflict)
+)
+
+func internalError(message string) *apiError {
+	return newAPIError(ErrorNotFound, message, http.StatusInternalServerError)
+}
+
+func getPR(ctx context.Context, store storage.Store, prID string) (*models.PullRequest, error) {
+	return loadPR(store.GetPRByID(ctx, prID))
+}
+
+// lockPR reads the PR for a change made inside InTx; concurrent changes to the
+// same PR wait until the transaction ends.
+func lockPR(ctx context.Context, tx storage.Store, prID string) (*models.PullRequest, error) {
+	return loadPR(tx.GetPRForUpdate(ctx, prID))
+}
+
+func loadPR(pr *models.PullRequest, err error) (*models.PullRequest, error) {
+	if err != nil {
+		return nil, storeError(err, "Failed to get PR")
+	}
+	if pr == nil {
+		return nil, errPRNotFound
+	}
+	return pr, nil
+}
+
+// inTx runs fn in one storage transaction. A failure of the transaction
+// itself is reported like any other storage error.
+func inTx(ctx context.Context, store storage.Store, message string, fn func(tx storage.Store) error) error {
+	err := store.InTx(ctx, fn)
+	if _, ok := err.(*apiError); err != nil && !ok {
+		return storeError(err, message)
+	}
+	return err
+}
+
+// reviewRequest describes the reviewers wanted for a PR. A preview picks
+// them as the next real selection would without moving the assigner on.
+type reviewRequest struct {
+	prID         string
+	authorID     string
+	exclude      []string
+	changedFiles []string
+	needs        assignment.Needs
+	count        int
+	preview      bool
+}
+
+// selection is the outcome of picking reviewers: who was chosen, who was
+// passed over on the way, which required tags none of them hold and whether
+// one of them has the required role. inputs replay it.
+type selection struct {
+	reviewers     []string
+	skipped       []models.SkippedReviewer
+	uncoveredTags []string
+	roleMet       bool
+	rotation      string
+	inputs        assignment.Inputs
+}
+
+// recordAssignment logs the selection with everything it depended on, for
+// /pullRequest/explain.
+func recordAssignment(ctx context.Context, store storage.Store, prID, assignedBy string, picked selection) error {
+	record, err := picked.inputs.Record(prID, assignedBy, picked.reviewers, picked.rotation)
+	if err != nil {
+		return internalError("Failed to record assignment")
+	}
+	if err := store.AddAssignmentRecord(ctx, record); err != nil {
+		return storeError(err, "Failed to record assignment")
+	}
+	return nil
+}
+
+// checkPRID rejects ids that do not fit the pull_request_id columns.
+func checkPRID(prID string) error {
+	if len(prID) > models.MaxPullRequestIDLength {
+		return newAPIError(ErrorInvalidPRID,
+			fmt.Sprintf("pull_request_id must be at most %d bytes", models.MaxPullRequestIDLength), http.StatusBadRequest)
+	}
+	return nil
+}
+
+// createPR checks the author, picks reviewers and stores the PR in one
+// transaction. Two requests racing on the same id are told apart by the
+// primary key, so exactly one of them succeeds.
+func createPR(ctx context.Context, store storage.Store, assigner *assignment.Assigner, pr models.PullRequest, draft bool) (*models.PullRequest, selection, error) {
+	if err := checkPRID(pr.PullRequestID); err != nil {
+		return nil, selection{}, err
+	}
+
+	tags, err := normalizeTags(pr.RequiredTags)
+	if err != nil {
+		return nil, selection{}, err
+	}
+	pr.RequiredTags = tags
+
+	picked := selection{skipped: []models.SkippedReviewer{}, uncoveredTags: []string{}}
+	err = inTx(ctx, store, "Failed to create PR", func(tx storage.Store) error {
+		existingPR, err := tx.GetPRByID(ctx, pr.PullRequestID)
+		if err != nil {
+			return storeError(err, "Failed to get PR")
+		}
+		if existingPR != nil {
+			return errPRExists
+		}
+
+		author, err := tx.GetUserByID(ctx, pr.AuthorID)
+		if err != nil {
+			return storeError(err, "Failed to get author")
+		}
+		if author == nil {
+			return newAPIError(ErrorNotFound, "Author not found", http.StatusNotFound)
+		}
+
+		pr.Status = models.StatusOpen
+		pr.AssignedReviewers = []string{}
+		pr.Version = 1
+		if draft {
+			pr.Status = models.StatusDraft
+		} else {
+			picked, err = initialReviewers(ctx, tx, assigner, author, &pr)
+			if err != nil {
+				return err
+			}
+			pr.AssignedReviewers = picked.reviewers
+		}
+
+		err = tx.CreatePR(ctx, pr)
+		if err == storage.ErrPRExists {
+			return errPRExists
+		}
+		if err != nil {
+			return storeError(err, "Failed to create PR")
+		}
+		if draft {
+			return nil
+		}
+		return recordAssignment(ctx, tx, pr.PullRequestID, models.AssignedByCreate, picked)
+	})
+	if err != nil {
+		return nil, selection{}, err
+	}
+
+	return &pr, picked, nil
+}
+
+// mergePR merges an open PR. When force is set the team's required approvals
+// are not checked, which is used when the merge already happened upstream.
+func mergePR(ctx context.Context, store storage.Store, prID string, force bool, version int64) (*models.PullRequest, error) {
+	var merged *models.PullRequest
+	err := inTx(ctx, store, "Failed to merge PR", func(tx storage.Store) error {
+		pr, err := lockPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(version, pr.Version); err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			merged = pr
+			return nil
+		}
+
+		if !models.CanTransition(pr.Status, models.StatusMerged) {
+			return newAPIError(ErrorInvalidTransition, fmt.Sprintf("cannot merge PR in status %s", pr.Status), http.StatusConflict)
+		}
+
+		if !force {
+			// An author who is no longer known has no team; the default
+			// settings apply then.
+			authorTeam, err := tx.GetUserTeam(ctx, pr.AuthorID)
+			if err != nil && err != sql.ErrNoRows {
+				return storeError(err, "Failed to get author team")
+			}
+			settings, err := teamSettings(ctx, tx, authorTeam)
+			if err != nil {
+				return storeError(err, "Failed to get team settings")
+			}
+
+			if pr.Approvals() < settings.RequiredApprovals {
+				return newAPIError(ErrorApprovalsRequired,
+					fmt.Sprintf("merge requires %d approvals, got %d", settings.RequiredApprovals, pr.Approvals()),
+					http.StatusConflict)
+			}
+		}
+
+		err = tx.UpdatePRStatus(ctx, prID, models.StatusMerged)
+		if err == storage.ErrInvalidTransition {
+			return errConcurrentStatusSet
+		}
+		if err != nil {
+			return storeError(err, "Failed to merge PR")
+		}
+
+		merged, err = getPR(ctx, tx, prID)
+		return err
+	})
+	return merged, err
+}
+
+// setPRStatus closes or reopens a PR. A PR reopened without reviewers, such
+// as a draft closed before it was ever ready, gets its first reviewers in the
+// same transaction, so no active PR is left without review.
+func setPRStatus(ctx context.Context, store storage.Store, assigner *assignment.Assigner, prID, status string, version int64) (*models.PullRequest, selection, error) {
+	var updated *models.PullRequest
+	var picked selection
+	err := inTx(ctx, store, "Failed to update PR status", func(tx storage.Store) error {
+		pr, err := lockPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(version, pr.Version); err != nil {
+			return err
+		}
+
+		if pr.Status == status {
+			updated = pr
+			return nil
+		}
+
+		if !models.CanTransition(pr.Status, status) {
+			return newAPIError(ErrorInvalidTransition,
+				fmt.Sprintf("cannot change PR status from %s to %s", pr.Status, status), http.StatusConflict)
+		}
+
+		assign := models.IsActiveStatus(status) && len(pr.AssignedReviewers) == 0
+		if assign {
+			picked, err = pickInitialReviewers(ctx, tx, assigner, pr)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := updatePRStatus(ctx, tx, prID, status); err != nil {
+			return err
+		}
+
+		if assign {
+			if err := storeInitialReviewers(ctx, tx, prID, picked); err != nil {
+				return err
+			}
+		}
+
+		updated, err = getPR(ctx, tx, prID)
+		return err
+	})
+	return updated, picked, err
+}
+
+func markReadyForReview(ctx context.Context, store storage.Store, assigner *assignment.Assigner, prID string, version int64) (*models.PullRequest, selection, error) {
+	var updated *models.PullRequest
+	var picked selection
+	err := inTx(ctx, store, "Failed to update PR status", func(tx storage.Store) error {
+		pr, err := lockPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(version, pr.Version); err != nil {
+			return err
+		}
+
+		if pr.Status != models.StatusDraft {
+			return newAPIError(ErrorInvalidTransition,
+				fmt.Sprintf("only DRAFT PRs can be marked ready, PR is %s", pr.Status), http.StatusConflict)
+		}
+
+		picked, err = pickInitialReviewers(ctx, tx, assigner, pr)
+		if err != nil {
+			return err
+		}
+
+		if err := updatePRStatus(ctx, tx, prID, models.StatusOpen); err != nil {
+			return err
+		}
+
+		if err := storeInitialReviewers(ctx, tx, prID, picked); err != nil {
+			return err
+		}
+
+		updated, err = getPR(ctx, tx, prID)
+		return err
+	})
+	return updated, picked, err
+}
+
+func updatePRStatus(ctx context.Context, tx storage.Store, prID, status string) error {
+	err := tx.UpdatePRStatus(ctx, prID, status)
+	if err == storage.ErrInvalidTransition {
+		return errConcurrentStatusSet
+	}
+	if err != nil {
+		return storeError(err, "Failed to update PR status")
+	}
+	return nil
+}
+
+// pickInitialReviewers selects the first reviewers of a PR that is becoming
+// active without any.
+func pickInitialReviewers(ctx context.Context, tx storage.Store, assigner *assignment.Assigner, pr *models.PullRequest) (selection, error) {
+	author, err := tx.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return selection{}, storeError(err, "Failed to get author")
+	}
+	if author == nil {
+		return selection{}, newAPIError(ErrorNotFound, "Author not found", http.StatusNotFound)
+	}
+
+	return initialReviewers(ctx, tx, assigner, author, pr)
+}
+
+func storeInitialReviewers(ctx context.Context, tx storage.Store, prID string, picked selection) error {
+	err := tx.UpdatePRReviewers(ctx, prID, picked.reviewers)
+	if err != nil {
+		return storeError(err, "Failed to assign reviewers")
+	}
+	return recordAssignment(ctx, tx, prID, models.AssignedByUpdate, picked)
+}
+
+func checkReviewable(pr *models.PullRequest, action string) error {
+	if pr.Status == models.StatusMerged {
+		return newAPIError(ErrorPRMerged, fmt.Sprintf("cannot %s on merged PR", action), http.StatusConflict)
+	}
+	if !models.IsActiveStatus(pr.Status) {
+		return newAPIError(ErrorPRNotOpen, fmt.Sprintf("cannot %s on PR in status %s", action, pr.Status), http.StatusConflict)
+	}
+	return nil
+}
+
+// submitReview checks and stores the verdict under the PR's row lock, so a
+// concurrent merge or reassign cannot slip in between.
+func submitReview(ctx context.Context, store storage.Store, prID, reviewerID, verdict, comment string) (*models.PullRequest, error) {
+	if !models.IsValidVerdict(verdict) {
+		return nil, newAPIError(ErrorInvalidVerdict, "verdict must be APPROVED, CHANGES_REQUESTED or COMMENTED", http.StatusBadRequest)
+	}
+
+	notAssigned := newAPIError(ErrorNotAssigned, "reviewer is not assigned to this PR", http.StatusConflict)
+
+	var updated *models.PullRequest
+	err := inTx(ctx, store, "Failed to save review", func(tx storage.Store) error {
+		pr, err := lockPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkReviewable(pr, "review"); err != nil {
+			return err
+		}
+
+		if !contains(pr.AssignedReviewers, reviewerID) {
+			return notAssigned
+		}
+
+		err = tx.SetReviewVerdict(ctx, prID, reviewerID, verdict, comment)
+		if err == sql.ErrNoRows {
+			return notAssigned
+		}
+		if err != nil {
+			return storeError(err, "Failed to save review")
+		}
+
+		updated, err = getPR(ctx, tx, prID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// reassignReviewer picks and stores the replacement in one transaction that
+// holds the PR's row lock, so parallel reassigns on a PR cannot both pick the
+// same candidate or overwrite each other.
+func reassignReviewer(ctx context.Context, store storage.Store, assigner *assignment.Assigner, prID, oldUserID string, version int64) (*models.PullRequest, selection, error) {
+	var updated *models.PullRequest
+	var selected selection
+	err := inTx(ctx, store, "Failed to update PR reviewers", func(tx storage.Store) error {
+		pr, err := lockPR(ctx, tx, prID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkVersion(version, pr.Version); err != nil {
+			return err
+		}
+
+		if err := checkReviewable(pr, "reassign"); err != nil {
+			return err
+		}
+
+		if !contains(pr.AssignedReviewers, oldUserID) {
+			return newAPIError(ErrorNotAssigned, "reviewer is not assigned to this PR", http.StatusConflict)
+		}
+
+		oldReviewer, err := tx.GetUserByID(ctx, oldUserID)
+		if err != nil {
+			return storeError(err, "Failed to get reviewer team")
+		}
+		if oldReviewer == nil {
+			return newAPIError(ErrorNotFound, "Old reviewer team not found", http.StatusNotFound)
+		}
+
+		settings, err := teamSettings(ctx, tx, oldReviewer.TeamName)
+		if err != nil {
+			return storeError(err, "Failed to get team settings")
+		}
+
+		// The replacement only has to bring the tags the reviewers who stay
+		// do not already hold.
+		var staying []string
+		for _, reviewer := range pr.AssignedReviewers {
+			if reviewer != oldUserID {
+				staying = append(staying, reviewer)
+			}
+		}
+		tags, err := tagsMissingFrom(ctx, tx, pr.RequiredTags, staying)
+		if err != nil {
+			return storeError(err, "Failed to get reviewers")
+		}
+
+		// Someone who meets the team's role rule is only replaced by someone
+		// else who does.
+		needs := assignment.Needs{Tags: tags}
+		if settings.MinReviewerRole != "" && models.HasRoleAtLeast(oldReviewer.Role, settings.MinReviewerRole) {
+			needs.Role = settings.MinReviewerRole
+		}
+
+		selected, err = selectReviewers(ctx, tx, assigner, settings, reviewRequest{
+			prID:         pr.PullRequestID,
+			authorID:     pr.AuthorID,
+			exclude:      pr.AssignedReviewers,
+			changedFiles: pr.ChangedFiles,
+			needs:        needs,
+			count:        1,
+		})
+		if err != nil {
+			return storeError(err, "Failed to get team members")
+		}
+
+		if !selected.roleMet {
+			return roleRequired(needs.Role, selected.skipped)
+		}
+		if len(selected.reviewers) == 0 {
+			return newAPIError(ErrorNoCandidate, withSkipped("no active replacement candidate in team", selected.skipped), http.StatusConflict)
+		}
+
+		err = tx.ReplacePRReviewer(ctx, prID, oldUserID, selected.reviewers[0])
+		if err != nil {
+			return storeError(err, "Failed to update PR reviewers")
+		}
+		if err := recordAssignment(ctx, tx, prID, models.AssignedByReassign, selected); err != nil {
+			return err
+		}
+
+		updated, err = getPR(ctx, tx, prID)
+		return err
+	})
+	if err != nil {
+		return nil, selection{}, err
+	}
+
+	return updated, selected, nil
+}
+
+func initialReviewers(ctx context.Context, store storage.Store, assigner *assignment.Assigner, author *models.User, pr *models.PullRequest) (selection, error) {
+	settings, err := teamSettings(ctx, store, author.TeamName)
+	if err != nil {
+		return selection{}, storeError(err, "Failed to get team settings")
+	}
+
+	selected, err := selectReviewers(ctx, store, assigner, settings, initialRequest(settings, author, pr))
+	if err != nil {
+		return selection{}, storeError(err, "Failed to select reviewers")
+	}
+
+	if err := checkInitial(settings, selected); err != nil {
+		return selection{}, err
+	}
+	return selected, nil
+}
+
+// initialRequest asks for the first reviewers of a PR.
+func initialRequest(settings models.TeamSettings, author *models.User, pr *models.PullRequest) reviewRequest {
+	return reviewRequest{
+		prID:         pr.PullRequestID,
+		authorID:     author.UserID,
+		changedFiles: pr.ChangedFiles,
+		needs:        assignment.Needs{Tags: pr.RequiredTags, Role: settings.MinReviewerRole},
+		count:        settings.MaxReviewers,
+	}
+}
+
+// checkInitial rejects first reviewers that break the team's rules.
+func checkInitial(settings models.TeamSettings, selected selection) error {
+	if !selected.roleMet {
+		return roleRequired(settings.MinReviewerRole, selected.skipped)
+	}
+
+	if len(selected.reviewers) < settings.MinReviewers {
+		if len(selected.skipped) > 0 {
+			return newAPIError(ErrorNotEnoughReviewers,
+				withSkipped(errNotEnoughReviewers.message, selected.skipped), http.StatusConflict)
+		}
+		return errNotEnoughReviewers
+	}
+	return nil
+}
+
+// withSkipped notes in an error message how many candidates were passed over,
+// e.g. for being away or at their review limit.
+func withSkipped(message string, skipped []models.SkippedReviewer) string {
+	if len(skipped) == 0 {
+		return message
+	}
+	return fmt.Sprintf("%s (%d candidates skipped)", message, len(skipped))
+}
+
+// roleRequired reports that nobody with the team's minimum reviewer role
+// could be assigned.
+func roleRequired(role string, skipped []models.SkippedReviewer) error {
+	return newAPIError(ErrorReviewerRoleRequired,
+		withSkipped(fmt.Sprintf("no available reviewer with role %s or above", role), skipped), http.StatusConflict)
+}
+
+func teamSettings(ctx context.Context, store storage.Store, teamName string) (models.TeamSettings, error) {
+	settings, err := store.GetTeamSettings(ctx, teamName)
+	if err != nil {
+		return models.TeamSettings{}, err
+	}
+	if settings == nil {
+		return assignment.DefaultSettings(teamName), nil
+	}
+	return *settings, nil
+}
+
+func selectReviewers(ctx context.Context, store storage.Store, assigner *assignment.Assigner, settings models.TeamSettings, request reviewRequest) (selection, error) {
+	authorID := request.authorID
+
+	owners, err := fileOwners(ctx, store, settings, authorID, request.changedFiles)
+	if err != nil {
+		return selection{}, err
+	}
+
+	preferred, err := candidatesFor(ctx, store, settings, request, owners)
+	if err != nil {
+		return selection{}, err
+	}
+
+	members, err := store.GetActiveTeamMembers(ctx, settings.TeamName, authorID)
+	if err != nil {
+		return selection{}, err
+	}
+
+	home, err := candidatesFor(ctx, store, settings, request, members)
+	if err != nil {
+		return selection{}, err
+	}
+
+	// Owners of the changed files come first, then the team. Partner teams
+	// are tried in the order the team lists them; anyone outside the team
+	// comes last, and only if the team allows it. Those are also tried when
+	// nobody so far holds one of the required tags or has the required role.
+	pools := [][]assignment.Candidate{preferred, home}
+	if eligibleCount(pools) < request.count || !assignment.Covers(pools, request.needs) {
+		for _, team := range settings.FallbackTeams {
+			partners, err := store.GetActiveTeamMembers(ctx, team, authorID)
+			if err != nil {
+				return selection{}, err
+			}
+
+			pool, err := candidatesFor(ctx, store, settings, request, partners)
+			if err != nil {
+				return selection{}, err
+			}
+			pools = append(pools, pool)
+		}
+
+		if settings.AllowCrossTeamFallback {
+			others, err := store.GetActiveUsersOutsideTeam(ctx, settings.TeamName, authorID)
+			if err != nil {
+				return selection{}, err
+			}
+
+			pool, err := candidatesFor(ctx, store, settings, request, others)
+			if err != nil {
+				return selection{}, err
+			}
+			pools = append(pools, pool)
+		}
+	}
+
+	// Outside a preview the team stays locked until the selection is
+	// recorded, so concurrent selections continue the round robin in turn.
+	teamRotation, run := store.TeamRotationForUpdate, assigner.Run
+	if request.preview {
+		teamRotation, run = store.TeamRotation, assigner.Preview
+	}
+	rotation, err := teamRotation(ctx, settings.TeamName)
+	if err != nil {
+		return selection{}, err
+	}
+
+	inputs, result := run(assignment.NewInputs(settings, pools, request.needs, request.count, rotation))
+	return selection{
+		reviewers:     result.Reviewers,
+		skipped:       result.Skipped,
+		uncoveredTags: result.UncoveredTags,
+		roleMet:       result.RoleMet,
+		rotation:      result.Rotation,
+		inputs:        inputs,
+	}, nil
+}
+
+// eligibleCount is the number of distinct users in pools who can take a
+// review.
+func eligibleCount(pools [][]assignment.Candidate) int {
+	eligible := make(map[string]bool)
+	for _, pool := range pools {
+		available, _ := assignment.Eligible(pool)
+		for _, candidate := range available {
+			eligible[candidate.UserID] = true
+		}
+	}
+	return len(eligible)
+}
+
+func candidatesFor(ctx context.Context, store storage.Store, settings models.TeamSettings, request reviewRequest, users []models.User) ([]assignment.Candidate, error) {
+	var userIDs []string
+	byID := make(map[string]models.User)
+	for _, user := range users {
+		if !contains(request.exclude, user.UserID) {
+			userIDs = append(userIDs, user.UserID)
+			byID[user.UserID] = user
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil, nil
+	}
+
+	openReviews, err := store.GetOpenReviewCounts(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := store.GetReviewLimits(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	away, err := store.GetUnavailableUsers(ctx, userIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := store.GetReviewerExclusions(ctx, request.authorID, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	repeats, err := store.GetRepeatReviewers(ctx, request.authorID, request.prID, settings.MaxConsecutiveReviews)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]assignment.Candidate, len(userIDs))
+	for i, userID := range userIDs {
+		candidates[i] = assignment.Candidate{
+			UserID:      userID,
+			OpenReviews: openReviews[userID],
+			Skills:      byID[userID].Skills,
+			Role:        byID[userID].Role,
+		}
+		if limit, ok := limits[userID]; ok {
+			candidates[i].MaxOpenReviews = &limit
+		}
+		if period, ok := away[userID]; ok {
+			candidates[i].Away = &period
+		}
+		if exclusion, ok := excluded[userID]; ok {
+			candidates[i].Exclusion = &exclusion
+		}
+		if contains(repeats, userID) {
+			candidates[i].Repeats = settings.MaxConsecutiveReviews
+		}
+	}
+	return candidates, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+// scoredCandidate is a user who could review the previewed PR. Score is
+// informational: it ranks the list, but no strategy selects by it.
+type scoredCandidate struct {
+	UserID         string  `json:"user_id"`
+	Score          float64 `json:"score"`
+	OpenReviews    int     `json:"open_reviews"`
+	MaxOpenReviews *int    `json:"max_open_reviews,omitempty"`
+	CodeOwner      bool    `json:"code_owner"`
+	Selected       bool    `json:"selected"`
+}
+
+// preview is who createPR would assign to a PR and why everyone else would
+// be left out. Rejection is the error createPR would fail with, if any.
+type preview struct {
+	TeamName      string                   `json:"team_name"`
+	Strategy      string                   `json:"strategy"`
+	Reviewers     []string                 `json:"reviewers"`
+	Candidates    []scoredCandidate        `json:"candidates"`
+	Excluded      []models.SkippedReviewer `json:"excluded"`
+	UncoveredTags []string                 `json:"uncovered_tags"`
+	RoleMet       bool                     `json:"role_met"`
+	Rejection     *ErrorResponse           `json:"rejection,omitempty"`
+}
+
+// previewPR runs the selection createPR would, reading only. Inactive users
+// are reported from the author's team; anyone else who could not review is
+// reported with the reason selection would skip them for.
+func previewPR(ctx context.Context, store storage.Store, assigner *assignment.Assigner, pr models.PullRequest) (*preview, error) {
+	tags, err := normalizeTags(pr.RequiredTags)
+	if err != nil {
+		return nil, err
+	}
+	pr.RequiredTags = tags
+
+	author, err := store.GetUserByID(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, storeError(err, "Failed to get author")
+	}
+	if author == nil {
+		return nil, newAPIError(ErrorNotFound, "Author not found", http.StatusNotFound)
+	}
+
+	settings, err := teamSettings(ctx, store, author.TeamName)
+	if err != nil {
+		return nil, storeError(err, "Failed to get team settings")
+	}
+
+	request := initialRequest(settings, author, &pr)
+	request.preview = true
+	selected, err := selectReviewers(ctx, store, assigner, settings, request)
+	if err != nil {
+		return nil, storeError(err, "Failed to select reviewers")
+	}
+
+	result := &preview{
+		TeamName:      settings.TeamName,
+		Strategy:      selected.inputs.Strategy,
+		Reviewers:     selected.reviewers,
+		Candidates:    []scoredCandidate{},
+		Excluded:      []models.SkippedReviewer{{UserID: author.UserID, Reason: models.SkipAuthor}},
+		UncoveredTags: selected.uncoveredTags,
+		RoleMet:       selected.roleMet,
+	}
+
+	// The rejections are checked in the order createPR checks them.
+	rejection := checkPRID(pr.PullRequestID)
+	if rejection == nil {
+		existing, err := store.GetPRByID(ctx, pr.PullRequestID)
+		if err != nil {
+			return nil, storeError(err, "Failed to get PR")
+		}
+		if existing != nil {
+			rejection = errPRExists
+		}
+	}
+	if rejection == nil {
+		rejection = checkInitial(settings, selected)
+	}
+	if rejection != nil {
+		apiErr, ok := rejection.(*apiError)
+		if !ok {
+			return nil, rejection
+		}
+		result.Rejection = errorResponse(apiErr)
+	}
+
+	team, err := store.GetTeam(ctx, author.TeamName)
+	if err != nil {
+		return nil, storeError(err, "Failed to get team")
+	}
+	if team != nil {
+		for _, member := range team.Members {
+			if !member.IsActive && member.UserID != author.UserID {
+				result.Excluded = append(result.Excluded, models.SkippedReviewer{UserID: member.UserID, Reason: models.SkipInactive})
+			}
+		}
+	}
+
+	var considered []assignment.Candidate
+	owners := make(map[string]bool)
+	seen := make(map[string]bool)
+	for i, pool := range selected.inputs.Pools {
+		for _, candidate := range pool {
+			if i == 0 {
+				owners[candidate.UserID] = true
+			}
+			if !seen[candidate.UserID] {
+				seen[candidate.UserID] = true
+				considered = append(considered, candidate)
+			}
+		}
+	}
+
+	available, skipped := assignment.Eligible(considered)
+	result.Excluded = append(result.Excluded, skipped...)
+	for _, candidate := range available {
+		result.Candidates = append(result.Candidates, scoredCandidate{
+			UserID:         candidate.UserID,
+			Score:          assignment.Score(candidate, request.needs),
+			OpenReviews:    candidate.OpenReviews,
+			MaxOpenReviews: candidate.MaxOpenReviews,
+			CodeOwner:      owners[candidate.UserID],
+			Selected:       contains(selected.reviewers, candidate.UserID),
+		})
+	}
+	sort.SliceStable(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Score > result.Candidates[j].Score
+	})
+
+	return result, nil
+}
+
+// PreviewPRHandler shows who /pullRequest/create would assign for the same
+// body, without storing anything.
+func PreviewPRHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		PullRequestID   string   `json:"pull_request_id"`
+		PullRequestName string   `json:"pull_request_name"`
+		AuthorID        string   `json:"author_id"`
+		ChangedFiles    []string `json:"changed_files"`
+		RequiredTags    []string `json:"required_tags"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	result, err := previewPR(r.Context(), store, assigner, models.PullRequest{
+		PullRequestID:   request.PullRequestID,
+		PullRequestName: request.PullRequestName,
+		AuthorID:        request.AuthorID,
+		ChangedFiles:    request.ChangedFiles,
+		RequiredTags:    request.RequiredTags,
+	})
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
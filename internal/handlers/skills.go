@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"strings"
+)
+
+// normalizeTag trims and lowercases a skill tag so "Postgres " and
+// "postgres" are the same tag.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", newAPIError(ErrorInvalidTags, "skill tags must not be empty", http.StatusBadRequest)
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes a PR's required tags and drops duplicates.
+func normalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		tag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
+
+// normalizeSkills normalizes a user's skill tags and checks each
+// proficiency is within range.
+func normalizeSkills(skills map[string]int) (map[string]int, error) {
+	if len(skills) == 0 {
+		return nil, nil
+	}
+
+	normalized := make(map[string]int, len(skills))
+	for tag, level := range skills {
+		normalizedTag, err := normalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if level < models.MinSkillLevel || level > models.MaxSkillLevel {
+			return nil, newAPIError(ErrorInvalidTags,
+				fmt.Sprintf("skill %q: proficiency must be between %d and %d", tag, models.MinSkillLevel, models.MaxSkillLevel),
+				http.StatusBadRequest)
+		}
+		if level > normalized[normalizedTag] {
+			normalized[normalizedTag] = level
+		}
+	}
+	return normalized, nil
+}
+
+// tagsMissingFrom lists the tags none of the given reviewers hold.
+func tagsMissingFrom(ctx context.Context, store storage.Store, tags, reviewerIDs []string) ([]string, error) {
+	held := make(map[string]bool)
+	for _, reviewerID := range reviewerIDs {
+		reviewer, err := store.GetUserByID(ctx, reviewerID)
+		if err != nil {
+			return nil, err
+		}
+		if reviewer == nil {
+			continue
+		}
+		for tag := range reviewer.Skills {
+			held[tag] = true
+		}
+	}
+
+	var missing []string
+	for _, tag := range tags {
+		if !held[tag] {
+			missing = append(missing, tag)
+		}
+	}
+	return missing, nil
+}
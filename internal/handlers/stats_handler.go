@@ -6,15 +6,15 @@ import (
 	"pr-reviewer-service/internal/storage"
 )
 
-func StatsHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func StatsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "GET" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	stats, err := store.GetReviewStats()
+	stats, err := store.GetReviewStats(r.Context())
 	if err != nil {
-		SendError(w, ErrorNotFound, "Failed to get stats", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to get stats"))
 		return
 	}
 
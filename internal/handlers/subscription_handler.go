@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+)
+
+func AddSubscriptionHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var sub models.WebhookSubscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	target, err := url.Parse(sub.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		SendError(w, ErrorInvalidSubscription, "url must be an absolute http(s) URL", http.StatusBadRequest)
+		return
+	}
+	for _, eventType := range sub.Events {
+		if !events.IsKnownType(eventType) {
+			SendError(w, ErrorInvalidSubscription, fmt.Sprintf("unknown event type %q", eventType), http.StatusBadRequest)
+			return
+		}
+	}
+
+	created, err := store.CreateWebhookSubscription(r.Context(), sub)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to create subscription"))
+		return
+	}
+	created.Secret = ""
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscription": created,
+	})
+}
+
+func ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	subs, err := store.ListWebhookSubscriptions(r.Context())
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to list subscriptions"))
+		return
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"subscriptions": subs,
+	})
+}
+
+func DeleteSubscriptionHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		ID int64 `json:"id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	err := store.DeleteWebhookSubscription(r.Context(), request.ID)
+	if err == sql.ErrNoRows {
+		SendError(w, ErrorNotFound, "subscription not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to delete subscription"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"deleted": request.ID,
+	})
+}
+
+func ListDeadLettersHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	letters, err := store.ListDeadLetters(r.Context())
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to list dead letters"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"dead_letters": letters,
+	})
+}
@@ -1,35 +1,81 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/codeowners"
 	"pr-reviewer-service/internal/models"
 	"pr-reviewer-service/internal/storage"
+	"strings"
 )
 
-func AddTeamHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+// maxCodeownersSize caps an uploaded CODEOWNERS file; GitHub ignores files
+// larger than 3 MB.
+const maxCodeownersSize = 3 << 20
+
+func AddTeamHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "POST" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	var team models.Team
+	store = withActor(r, store)
+
+	settings := assignment.DefaultSettings("")
+	team := models.Team{Settings: &settings}
 	if err := json.NewDecoder(r.Body).Decode(&team); err != nil {
 		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if team.Settings == nil {
+		team.Settings = &settings
+	}
+	team.Settings.TeamName = team.TeamName
+	team.Settings.Version = 1
+
+	if err := assignment.ValidateSettings(*team.Settings); err != nil {
+		SendError(w, ErrorInvalidSettings, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for i, member := range team.Members {
+		skills, err := normalizeSkills(member.Skills)
+		if err != nil {
+			sendAPIError(w, err)
+			return
+		}
+		team.Members[i].Skills = skills
+
+		if member.Role != "" && !models.IsValidRole(member.Role) {
+			SendError(w, ErrorInvalidRole, "role must be junior, mid, senior or maintainer", http.StatusBadRequest)
+			return
+		}
+	}
 
-	existingTeam, _ := store.GetTeam(team.TeamName)
+	existingTeam, err := store.GetTeam(r.Context(), team.TeamName)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get team"))
+		return
+	}
 	if existingTeam != nil {
 		SendError(w, ErrorTeamExists, "team_name already exists", http.StatusBadRequest)
 		return
 	}
 
-	err := store.CreateTeam(team)
+	if err := checkFallbackTeams(r.Context(), store, *team.Settings); err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	err = store.CreateTeam(r.Context(), team)
 	if err != nil {
 		log.Printf("Error creating team: %v", err)
-		SendError(w, ErrorNotFound, "Failed to create team", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to create team"))
 		return
 	}
 
@@ -40,7 +86,7 @@ func AddTeamHandler(w http.ResponseWriter, r *http.Request, store *storage.Stora
 	})
 }
 
-func GetTeamHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func GetTeamHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "GET" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -52,12 +98,233 @@ func GetTeamHandler(w http.ResponseWriter, r *http.Request, store *storage.Stora
 		return
 	}
 
-	team, err := store.GetTeam(teamName)
-	if err != nil || team == nil {
+	team, err := store.GetTeam(r.Context(), teamName)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get team"))
+		return
+	}
+	if team == nil {
 		SendError(w, ErrorNotFound, "Team not found", http.StatusNotFound)
 		return
 	}
 
+	setETag(w, team.Settings.Version)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(team)
 }
+
+func GetTeamSettingsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "GET" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		SendError(w, ErrorNotFound, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	settings, err := store.GetTeamSettings(r.Context(), teamName)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get team settings"))
+		return
+	}
+	if settings == nil {
+		SendError(w, ErrorNotFound, "Team not found", http.StatusNotFound)
+		return
+	}
+
+	setETag(w, settings.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+func UpdateTeamSettingsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		TeamName               string                  `json:"team_name"`
+		MinReviewers           *int                    `json:"min_reviewers"`
+		MaxReviewers           *int                    `json:"max_reviewers"`
+		AssignmentStrategy     *string                 `json:"assignment_strategy"`
+		AllowCrossTeamFallback *bool                   `json:"allow_cross_team_fallback"`
+		RequiredApprovals      *int                    `json:"required_approvals"`
+		FallbackTeams          *[]string               `json:"fallback_teams"`
+		MaxOpenReviews         *int                    `json:"max_open_reviews"`
+		OwnershipRules         *[]models.OwnershipRule `json:"ownership_rules"`
+		MinReviewerRole        *string                 `json:"min_reviewer_role"`
+		MaxConsecutiveReviews  *int                    `json:"max_consecutive_reviews"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	settings, err := updateTeamSettings(r.Context(), store, request.TeamName, version, func(current *models.TeamSettings) {
+		if request.MinReviewers != nil {
+			current.MinReviewers = *request.MinReviewers
+		}
+		if request.MaxReviewers != nil {
+			current.MaxReviewers = *request.MaxReviewers
+		}
+		if request.AssignmentStrategy != nil {
+			current.AssignmentStrategy = *request.AssignmentStrategy
+		}
+		if request.AllowCrossTeamFallback != nil {
+			current.AllowCrossTeamFallback = *request.AllowCrossTeamFallback
+		}
+		if request.RequiredApprovals != nil {
+			current.RequiredApprovals = *request.RequiredApprovals
+		}
+		if request.FallbackTeams != nil {
+			current.FallbackTeams = *request.FallbackTeams
+		}
+		if request.MaxOpenReviews != nil {
+			current.MaxOpenReviews = *request.MaxOpenReviews
+		}
+		if request.OwnershipRules != nil {
+			current.OwnershipRules = *request.OwnershipRules
+		}
+		if request.MinReviewerRole != nil {
+			current.MinReviewerRole = *request.MinReviewerRole
+		}
+		if request.MaxConsecutiveReviews != nil {
+			current.MaxConsecutiveReviews = *request.MaxConsecutiveReviews
+		}
+	})
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	setETag(w, settings.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// updateTeamSettings applies change to the team's settings and stores them in
+// one transaction, provided the team is still at version.
+func updateTeamSettings(ctx context.Context, store storage.Store, teamName string, version int64,
+	change func(current *models.TeamSettings)) (*models.TeamSettings, error) {
+	var settings *models.TeamSettings
+	err := inTx(ctx, store, "Failed to update team settings", func(tx storage.Store) error {
+		current, err := tx.GetTeamSettingsForUpdate(ctx, teamName)
+		if err != nil {
+			return storeError(err, "Failed to get team settings")
+		}
+		if current == nil {
+			return newAPIError(ErrorNotFound, "Team not found", http.StatusNotFound)
+		}
+
+		if err := checkVersion(version, current.Version); err != nil {
+			return err
+		}
+
+		change(current)
+
+		if err := assignment.ValidateSettings(*current); err != nil {
+			return newAPIError(ErrorInvalidSettings, err.Error(), http.StatusBadRequest)
+		}
+		if err := checkFallbackTeams(ctx, tx, *current); err != nil {
+			return err
+		}
+
+		if err := tx.UpdateTeamSettings(ctx, *current); err != nil {
+			return storeError(err, "Failed to update team settings")
+		}
+
+		settings, err = tx.GetTeamSettings(ctx, teamName)
+		if err != nil {
+			return storeError(err, "Failed to get team settings")
+		}
+		return nil
+	})
+	return settings, err
+}
+
+// UploadCodeownersHandler replaces the team's ownership rules with those of
+// a CODEOWNERS file, sent as the request body or as the "file" field of a
+// multipart form.
+func UploadCodeownersHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		SendError(w, ErrorNotFound, "team_name is required", http.StatusBadRequest)
+		return
+	}
+
+	var file io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		part, _, err := r.FormFile("file")
+		if err != nil {
+			SendError(w, ErrorInvalidSettings, "multipart upload must carry a file field", http.StatusBadRequest)
+			return
+		}
+		defer part.Close()
+		file = part
+	}
+
+	rules, err := codeowners.Parse(io.LimitReader(file, maxCodeownersSize))
+	if err != nil {
+		SendError(w, ErrorInvalidSettings, "CODEOWNERS "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	version, err := ifMatch(r)
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	settings, err := updateTeamSettings(r.Context(), store, teamName, version, func(current *models.TeamSettings) {
+		current.OwnershipRules = rules
+	})
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	setETag(w, settings.Version)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": settings,
+	})
+}
+
+// checkFallbackTeams rejects settings that name a fallback team which does
+// not exist.
+func checkFallbackTeams(ctx context.Context, store storage.Store, settings models.TeamSettings) error {
+	for _, team := range settings.FallbackTeams {
+		partner, err := store.GetTeamSettings(ctx, team)
+		if err != nil {
+			return storeError(err, "Failed to get team settings")
+		}
+		if partner == nil {
+			return newAPIError(ErrorInvalidSettings, fmt.Sprintf("fallback team %s not found", team), http.StatusBadRequest)
+		}
+	}
+	return nil
+}
@@ -8,12 +8,14 @@ import (
 	"pr-reviewer-service/internal/storage"
 )
 
-func SetUserActiveHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+func SetUserActiveHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "POST" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	store = withActor(r, store)
+
 	var request struct {
 		UserID   string `json:"user_id"`
 		IsActive bool   `json:"is_active"`
@@ -24,15 +26,68 @@ func SetUserActiveHandler(w http.ResponseWriter, r *http.Request, store *storage
 		return
 	}
 
-	err := store.UpdateUserActive(request.UserID, request.IsActive)
+	err := store.UpdateUserActive(r.Context(), request.UserID, request.IsActive)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to update user"))
+		return
+	}
+
+	user, err := store.GetUserByID(r.Context(), request.UserID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get user"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": user,
+	})
+}
+
+// SetUserMaxOpenReviewsHandler sets how many open PRs a user may review at
+// once. A null limit falls back to the team's default.
+func SetUserMaxOpenReviewsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		UserID         string `json:"user_id"`
+		MaxOpenReviews *int   `json:"max_open_reviews"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if request.MaxOpenReviews != nil && *request.MaxOpenReviews < 0 {
+		SendError(w, ErrorInvalidSettings, "max_open_reviews must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	user, err := store.GetUserByID(r.Context(), request.UserID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get user"))
+		return
+	}
+	if user == nil {
+		SendError(w, ErrorNotFound, "User not found", http.StatusNotFound)
+		return
+	}
+
+	err = store.SetUserMaxOpenReviews(r.Context(), request.UserID, request.MaxOpenReviews)
 	if err != nil {
-		http.Error(w, "Failed to update user", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to update user"))
 		return
 	}
 
-	user, err := store.GetUserByID(request.UserID)
+	user, err = store.GetUserByID(r.Context(), request.UserID)
 	if err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+		sendAPIError(w, storeError(err, "Failed to get user"))
 		return
 	}
 
@@ -42,7 +97,55 @@ func SetUserActiveHandler(w http.ResponseWriter, r *http.Request, store *storage
 	})
 }
 
-func GetUserReviewsHandler(w http.ResponseWriter, r *http.Request, store *storage.Storage) {
+// SetUserRoleHandler sets a user's reviewer role; an empty role clears it.
+func SetUserRoleHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	store = withActor(r, store)
+
+	var request struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		SendError(w, ErrorNotFound, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	if request.Role != "" && !models.IsValidRole(request.Role) {
+		SendError(w, ErrorInvalidRole, "role must be junior, mid, senior or maintainer", http.StatusBadRequest)
+		return
+	}
+
+	user, err := store.GetUserByID(r.Context(), request.UserID)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to get user"))
+		return
+	}
+	if user == nil {
+		SendError(w, ErrorNotFound, "User not found", http.StatusNotFound)
+		return
+	}
+
+	err = store.SetUserRole(r.Context(), request.UserID, request.Role)
+	if err != nil {
+		sendAPIError(w, storeError(err, "Failed to update user"))
+		return
+	}
+
+	user.Role = request.Role
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"user": user,
+	})
+}
+
+func GetUserReviewsHandler(w http.ResponseWriter, r *http.Request, store storage.Store) {
 	if r.Method != "GET" {
 		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
 		return
@@ -56,10 +159,10 @@ func GetUserReviewsHandler(w http.ResponseWriter, r *http.Request, store *storag
 
 	fmt.Printf("GetUserReviewsHandler called for user: %s\n", userID)
 
-	user, err := store.GetUserByID(userID)
+	user, err := store.GetUserByID(r.Context(), userID)
 	if err != nil {
 		fmt.Printf("GetUserByID error: %v\n", err)
-		SendError(w, ErrorNotFound, "Failed to get user", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to get user"))
 		return
 	}
 	if user == nil {
@@ -70,10 +173,10 @@ func GetUserReviewsHandler(w http.ResponseWriter, r *http.Request, store *storag
 
 	fmt.Printf("User found: %s, active: %v\n", userID, user.IsActive)
 
-	prs, err := store.GetPRsByReviewer(userID)
+	prs, err := store.GetPRsByReviewer(r.Context(), userID)
 	if err != nil {
 		fmt.Printf("GetPRsByReviewer error: %v\n", err)
-		SendError(w, ErrorNotFound, "Failed to get user reviews", http.StatusInternalServerError)
+		sendAPIError(w, storeError(err, "Failed to get user reviews"))
 		return
 	}
 
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/config"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+	"pr-reviewer-service/internal/webhooks"
+)
+
+const maxWebhookBodySize = 1 << 20
+
+func GitHubWebhookHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	receiveWebhook(w, r, store, assigner,
+		func(body []byte) bool {
+			return webhooks.VerifyGitHubSignature(config.GitHubWebhookSecret, body, r.Header.Get("X-Hub-Signature-256"))
+		},
+		func(body []byte) (*webhooks.Event, error) {
+			return webhooks.ParseGitHub(r.Header.Get("X-GitHub-Event"), body)
+		})
+}
+
+func GitLabWebhookHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	receiveWebhook(w, r, store, assigner,
+		func(body []byte) bool {
+			return webhooks.VerifyGitLabToken(config.GitLabWebhookSecret, r.Header.Get("X-Gitlab-Token"))
+		},
+		func(body []byte) (*webhooks.Event, error) {
+			return webhooks.ParseGitLab(r.Header.Get("X-Gitlab-Event"), body)
+		})
+}
+
+func GiteaWebhookHandler(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner) {
+	receiveWebhook(w, r, store, assigner,
+		func(body []byte) bool {
+			return webhooks.VerifyGiteaSignature(config.GiteaWebhookSecret, body, r.Header.Get("X-Gitea-Signature"))
+		},
+		func(body []byte) (*webhooks.Event, error) {
+			return webhooks.ParseGitea(r.Header.Get("X-Gitea-Event"), body)
+		})
+}
+
+// receiveWebhook is shared by all forge adapters: it authenticates the
+// delivery, parses it into a provider-neutral event and applies it.
+func receiveWebhook(w http.ResponseWriter, r *http.Request, store storage.Store, assigner *assignment.Assigner,
+	verify func(body []byte) bool, parse func(body []byte) (*webhooks.Event, error)) {
+	if r.Method != "POST" {
+		SendError(w, ErrorNotFound, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodySize))
+	if err != nil {
+		SendError(w, ErrorNotFound, "Failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	if !verify(body) {
+		SendError(w, ErrorInvalidSignature, "invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	event, err := parse(body)
+	if err != nil {
+		SendError(w, ErrorNotFound, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	handleWebhookEvent(r.Context(), w, store, assigner, event)
+}
+
+func handleWebhookEvent(ctx context.Context, w http.ResponseWriter, store storage.Store, assigner *assignment.Assigner, event *webhooks.Event) {
+	if event == nil {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"status": "ignored",
+		})
+		return
+	}
+
+	pr, err := applyWebhookEvent(ctx, store, assigner, event)
+	if err != nil {
+		sendAPIError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"event": event.Kind,
+		"pr":    pr,
+	})
+}
+
+func applyWebhookEvent(ctx context.Context, store storage.Store, assigner *assignment.Assigner, event *webhooks.Event) (*models.PullRequest, error) {
+	store = store.WithActor("webhook:" + event.Provider)
+
+	switch event.Kind {
+	case webhooks.KindOpened:
+		// Forges redeliver events, so an already known PR is not an error,
+		// even when a parallel delivery stores it first.
+		existing, err := store.GetPRByID(ctx, event.PullRequestID)
+		if err != nil {
+			return nil, storeError(err, "Failed to get PR")
+		}
+		if existing != nil {
+			return existing, nil
+		}
+		author, err := resolveLogin(ctx, store, event.Provider, event.AuthorLogin)
+		if err != nil {
+			return nil, err
+		}
+		pr, _, err := createPR(ctx, store, assigner, models.PullRequest{
+			PullRequestID:   event.PullRequestID,
+			PullRequestName: event.PullRequestName,
+			AuthorID:        author.UserID,
+		}, event.Draft)
+		if err == errPRExists {
+			return getPR(ctx, store, event.PullRequestID)
+		}
+		return pr, err
+	case webhooks.KindReadyForReview:
+		pr, _, err := markReadyForReview(ctx, store, assigner, event.PullRequestID, anyVersion)
+		return pr, err
+	case webhooks.KindReopened:
+		pr, _, err := setPRStatus(ctx, store, assigner, event.PullRequestID, models.StatusReopened, anyVersion)
+		return pr, err
+	case webhooks.KindClosed:
+		pr, _, err := setPRStatus(ctx, store, assigner, event.PullRequestID, models.StatusClosed, anyVersion)
+		return pr, err
+	case webhooks.KindMerged:
+		return mergePR(ctx, store, event.PullRequestID, true, anyVersion)
+	case webhooks.KindReviewSubmitted:
+		reviewer, err := resolveLogin(ctx, store, event.Provider, event.ReviewerLogin)
+		if err != nil {
+			return nil, err
+		}
+		return submitReview(ctx, store, event.PullRequestID, reviewer.UserID, event.Verdict, event.Comment)
+	}
+
+	return nil, newAPIError(ErrorNotFound, fmt.Sprintf("unsupported event %q", event.Kind), http.StatusBadRequest)
+}
+
+// resolveLogin maps a forge login to a user. Users without an explicit
+// mapping are looked up by user_id, so teams whose ids already match their
+// logins need no extra configuration.
+func resolveLogin(ctx context.Context, store storage.Store, provider, login string) (*models.User, error) {
+	user, err := store.GetUserByLogin(ctx, provider, login)
+	if err != nil {
+		return nil, storeError(err, "Failed to resolve login")
+	}
+	if user == nil {
+		user, err = store.GetUserByID(ctx, login)
+		if err != nil {
+			return nil, storeError(err, "Failed to resolve login")
+		}
+	}
+	if user == nil {
+		return nil, newAPIError(ErrorUnknownLogin, fmt.Sprintf("no user mapped to %s login %s", provider, login), http.StatusNotFound)
+	}
+	return user, nil
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AssignmentRecord is one reviewer selection for a PR: the strategy, the seed
+// of its random source and every input it depended on, so it can be replayed.
+type AssignmentRecord struct {
+	ID            int64           `json:"id"`
+	PullRequestID string          `json:"pull_request_id"`
+	TeamName      string          `json:"team_name"`
+	AssignedBy    string          `json:"assigned_by"`
+	Strategy      string          `json:"strategy"`
+	Seed          int64           `json:"seed"`
+	Inputs        json.RawMessage `json:"inputs"`
+	Reviewers     []string        `json:"reviewers"`
+	// Rotation is where the selection left the team's round robin.
+	Rotation  string    `json:"rotation,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
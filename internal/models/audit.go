@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditTeamCreated            = "team.created"
+	AuditTeamSettingsUpdated    = "team.settings_updated"
+	AuditTeamBulkDeactivated    = "team.bulk_deactivated"
+	AuditUserSaved              = "user.saved"
+	AuditUserActiveChanged      = "user.active_changed"
+	AuditUserReviewLimitChanged = "user.review_limit_changed"
+	AuditUserRoleChanged        = "user.role_changed"
+	AuditAvailabilityAdded      = "user.availability_added"
+	AuditAvailabilityUpdated    = "user.availability_updated"
+	AuditAvailabilityRemoved    = "user.availability_removed"
+	AuditPRCreated              = "pr.created"
+	AuditPRStatusChanged        = "pr.status_changed"
+	AuditPRReviewersUpdated     = "pr.reviewers_updated"
+	AuditReviewerReplaced       = "reviewer.replaced"
+	AuditReviewSubmitted        = "review.submitted"
+	AuditSubscriptionCreated    = "subscription.created"
+	AuditSubscriptionDeleted    = "subscription.deleted"
+	AuditExclusionAdded         = "exclusion.added"
+	AuditExclusionRemoved       = "exclusion.removed"
+)
+
+const (
+	EntityTeam         = "team"
+	EntityUser         = "user"
+	EntityPullRequest  = "pull_request"
+	EntitySubscription = "subscription"
+	EntityExclusion    = "reviewer_exclusion"
+)
+
+// DefaultActor is recorded for changes that were not made on behalf of a
+// known caller.
+const DefaultActor = "system"
+
+type AuditEvent struct {
+	ID            int64           `json:"id"`
+	Actor         string          `json:"actor"`
+	Action        string          `json:"action"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      string          `json:"entity_id"`
+	PullRequestID string          `json:"pull_request_id,omitempty"`
+	TeamName      string          `json:"team_name,omitempty"`
+	UserIDs       []string        `json:"user_ids,omitempty"`
+	Before        json.RawMessage `json:"before,omitempty"`
+	After         json.RawMessage `json:"after,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// AuditFilter selects audit events; zero fields do not filter. Results are
+// ordered by id and start after AfterID, which serves as the page cursor.
+type AuditFilter struct {
+	PullRequestID string
+	UserID        string
+	TeamName      string
+	Action        string
+	From          time.Time
+	To            time.Time
+	AfterID       int64
+	Limit         int
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// AvailabilityPeriod is a window in which a user takes no new reviews, e.g.
+// a vacation. It leaves is_active alone, so the user is back in rotation as
+// soon as the window ends.
+type AvailabilityPeriod struct {
+	ID     int64     `json:"id"`
+	UserID string    `json:"user_id"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason"`
+	// ReassignReviews asks for the user's open reviews to be handed to
+	// someone else once the period starts.
+	ReassignReviews     bool       `json:"reassign_reviews"`
+	ReviewsReassignedAt *time.Time `json:"reviews_reassigned_at,omitempty"`
+}
+
+// Covers reports whether at falls inside the period. The end is exclusive.
+func (p AvailabilityPeriod) Covers(at time.Time) bool {
+	return !at.Before(p.Start) && at.Before(p.End)
+}
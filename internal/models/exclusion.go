@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ReviewerExclusion bars ReviewerID from reviewing PRs by AuthorID, e.g. for
+// a conflict of interest or a manager and their report. It only works in that
+// direction.
+type ReviewerExclusion struct {
+	ReviewerID string    `json:"reviewer_id"`
+	AuthorID   string    `json:"author_id"`
+	Reason     string    `json:"reason,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
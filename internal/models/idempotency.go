@@ -0,0 +1,11 @@
+package models
+
+// IdempotentResponse is the first response to a POST sent with an
+// Idempotency-Key. StatusCode is zero while that request is still running.
+type IdempotentResponse struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	Headers     map[string]string
+	Body        []byte
+}
@@ -5,23 +5,169 @@ type User struct {
 	Username string `json:"username"`
 	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+	// MaxOpenReviews caps the user's concurrent open reviews; nil falls back
+	// to the team's max_open_reviews.
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty"`
+	// Logins maps a forge (e.g. "github") to the user's login there.
+	Logins map[string]string `json:"logins,omitempty"`
+	// Skills maps skill tags (e.g. "postgres") to a proficiency from
+	// MinSkillLevel to MaxSkillLevel.
+	Skills map[string]int `json:"skills,omitempty"`
+	// Role is one of the reviewer roles below; empty means no role.
+	Role string `json:"role,omitempty"`
+}
+
+const (
+	MinSkillLevel = 1
+	MaxSkillLevel = 5
+)
+
+// Reviewer roles, from least to most senior.
+const (
+	RoleJunior     = "junior"
+	RoleMid        = "mid"
+	RoleSenior     = "senior"
+	RoleMaintainer = "maintainer"
+)
+
+var roleRanks = map[string]int{RoleJunior: 1, RoleMid: 2, RoleSenior: 3, RoleMaintainer: 4}
+
+func IsValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// HasRoleAtLeast reports whether role is min or more senior. A user without
+// a role never qualifies.
+func HasRoleAtLeast(role, min string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[min]
 }
 
 type Team struct {
-	TeamName string `json:"team_name"`
-	Members  []User `json:"members"`
+	TeamName string        `json:"team_name"`
+	Settings *TeamSettings `json:"settings,omitempty"`
+	Members  []User        `json:"members"`
+}
+
+type TeamSettings struct {
+	TeamName               string   `json:"team_name"`
+	MinReviewers           int      `json:"min_reviewers"`
+	MaxReviewers           int      `json:"max_reviewers"`
+	AssignmentStrategy     string   `json:"assignment_strategy"`
+	AllowCrossTeamFallback bool     `json:"allow_cross_team_fallback"`
+	RequiredApprovals      int      `json:"required_approvals"`
+	FallbackTeams          []string `json:"fallback_teams"`
+	MaxOpenReviews         int      `json:"max_open_reviews"`
+	// OwnershipRules map path globs to owners, CODEOWNERS style; matching
+	// owners are preferred as reviewers of the changed files.
+	OwnershipRules []OwnershipRule `json:"ownership_rules"`
+	// MinReviewerRole, when set, requires at least one reviewer of every PR
+	// to have this role or a more senior one.
+	MinReviewerRole string `json:"min_reviewer_role"`
+	// MaxConsecutiveReviews, when positive, keeps a reviewer off an author's
+	// PR if they reviewed that many of the author's latest PRs in a row.
+	MaxConsecutiveReviews int   `json:"max_consecutive_reviews"`
+	Version               int64 `json:"version"`
 }
 
+// OwnershipRule names the owners of the paths matching Pattern. An owner is
+// a user id, a forge login or a team name ("org/team" refers to team).
+type OwnershipRule struct {
+	Pattern string   `json:"pattern"`
+	Owners  []string `json:"owners"`
+}
+
+// MaxPullRequestIDLength is the longest PR id the schema stores.
+const MaxPullRequestIDLength = 255
+
 type PullRequest struct {
 	PullRequestID     string   `json:"pull_request_id"`
 	PullRequestName   string   `json:"pull_request_name"`
 	AuthorID          string   `json:"author_id"`
 	Status            string   `json:"status"`
 	AssignedReviewers []string `json:"assigned_reviewers"`
+	ChangedFiles      []string `json:"changed_files,omitempty"`
+	RequiredTags      []string `json:"required_tags,omitempty"`
+	Reviews           []Review `json:"reviews,omitempty"`
 	CreatedAt         *string  `json:"createdAt,omitempty"`
 	MergedAt          *string  `json:"mergedAt,omitempty"`
+	ClosedAt          *string  `json:"closedAt,omitempty"`
+	Version           int64    `json:"version"`
 }
 
+const (
+	VerdictApproved         = "APPROVED"
+	VerdictChangesRequested = "CHANGES_REQUESTED"
+	VerdictCommented        = "COMMENTED"
+)
+
+func IsValidVerdict(verdict string) bool {
+	switch verdict {
+	case VerdictApproved, VerdictChangesRequested, VerdictCommented:
+		return true
+	}
+	return false
+}
+
+type Review struct {
+	ReviewerID string  `json:"reviewer_id"`
+	Verdict    string  `json:"verdict"`
+	Comment    string  `json:"comment,omitempty"`
+	ReviewedAt *string `json:"reviewed_at,omitempty"`
+}
+
+func (pr PullRequest) Approvals() int {
+	count := 0
+	for _, review := range pr.Reviews {
+		if review.Verdict == VerdictApproved {
+			count++
+		}
+	}
+	return count
+}
+
+const (
+	ReviewerStateAssigned = "ASSIGNED"
+	ReviewerStateReplaced = "REPLACED"
+	ReviewerStateRemoved  = "REMOVED"
+)
+
+const (
+	AssignedByCreate         = "create"
+	AssignedByReassign       = "reassign"
+	AssignedByBulkDeactivate = "bulk_deactivate"
+	AssignedByUpdate         = "update"
+)
+
+type ReviewerAssignment struct {
+	UserID     string  `json:"user_id"`
+	AssignedAt *string `json:"assigned_at,omitempty"`
+	AssignedBy string  `json:"assigned_by"`
+	SourceTeam *string `json:"source_team,omitempty"`
+	State      string  `json:"state"`
+	ReplacedBy *string `json:"replaced_by,omitempty"`
+	Verdict    *string `json:"verdict,omitempty"`
+}
+
+// SkippedReviewer is a user assignment passed over, with the reason.
+type SkippedReviewer struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+	Detail string `json:"detail,omitempty"`
+}
+
+const (
+	SkipAtCapacity     = "AT_CAPACITY"
+	SkipUnavailable    = "UNAVAILABLE"
+	SkipExcluded       = "EXCLUDED"
+	SkipRecentReviewer = "RECENT_REVIEWER"
+	// SkipAuthor and SkipInactive are only reported by previews; selection
+	// never considers those users at all.
+	SkipAuthor   = "AUTHOR"
+	SkipInactive = "INACTIVE"
+)
+
 type PullRequestShort struct {
 	PullRequestID   string `json:"pull_request_id"`
 	PullRequestName string `json:"pull_request_name"`
@@ -0,0 +1,32 @@
+package models
+
+const (
+	StatusDraft    = "DRAFT"
+	StatusOpen     = "OPEN"
+	StatusClosed   = "CLOSED"
+	StatusReopened = "REOPENED"
+	StatusMerged   = "MERGED"
+)
+
+var statusTransitions = map[string][]string{
+	StatusDraft:    {StatusOpen, StatusClosed},
+	StatusOpen:     {StatusMerged, StatusClosed},
+	StatusReopened: {StatusMerged, StatusClosed},
+	StatusClosed:   {StatusReopened},
+	StatusMerged:   {},
+}
+
+// IsActiveStatus reports whether a PR in this status is waiting for review
+// and counts towards reviewer load.
+func IsActiveStatus(status string) bool {
+	return status == StatusOpen || status == StatusReopened
+}
+
+func CanTransition(from, to string) bool {
+	for _, allowed := range statusTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package models
+
+type WebhookSubscription struct {
+	ID        int64    `json:"id"`
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret,omitempty"`
+	Events    []string `json:"events"`
+	CreatedAt *string  `json:"createdAt,omitempty"`
+}
+
+// Wants reports whether the subscription filters in eventType. An empty
+// filter subscribes to every event.
+func (s WebhookSubscription) Wants(eventType string) bool {
+	if len(s.Events) == 0 {
+		return true
+	}
+	for _, e := range s.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// DeadLetter is an event delivery that exhausted its retries.
+type DeadLetter struct {
+	ID             int64   `json:"id"`
+	SubscriptionID int64   `json:"subscription_id"`
+	EventID        string  `json:"event_id"`
+	EventType      string  `json:"event_type"`
+	Payload        string  `json:"payload"`
+	Attempts       int     `json:"attempts"`
+	LastError      string  `json:"last_error"`
+	FailedAt       *string `json:"failedAt,omitempty"`
+}
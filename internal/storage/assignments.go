@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (s *Storage) AddAssignmentRecord(ctx context.Context, record models.AssignmentRecord) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return insertAssignmentRecord(ctx, s.q(), record)
+}
+
+func insertAssignmentRecord(ctx context.Context, q querier, record models.AssignmentRecord) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO assignment_records (pull_request_id, team_name, assigned_by, strategy, seed, inputs, reviewers, rotation)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`, record.PullRequestID, record.TeamName, record.AssignedBy, record.Strategy, record.Seed, string(record.Inputs),
+		pq.Array(nonNil(record.Reviewers)), record.Rotation)
+	return err
+}
+
+func (s *Storage) TeamRotation(ctx context.Context, teamName string) (_ string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return teamRotation(ctx, s.q(), teamName)
+}
+
+func (s *Storage) TeamRotationForUpdate(ctx context.Context, teamName string) (_ string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	if _, err := s.q().ExecContext(ctx, "SELECT 1 FROM teams WHERE team_name = $1 FOR UPDATE", teamName); err != nil {
+		return "", err
+	}
+	return teamRotation(ctx, s.q(), teamName)
+}
+
+func teamRotation(ctx context.Context, q querier, teamName string) (string, error) {
+	var rotation string
+	err := q.QueryRowContext(ctx, `
+		SELECT rotation FROM assignment_records
+		WHERE team_name = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`, teamName).Scan(&rotation)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return rotation, err
+}
+
+func (s *Storage) ListAssignmentRecords(ctx context.Context, prID string) (_ []models.AssignmentRecord, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT id, pull_request_id, team_name, assigned_by, strategy, seed, inputs, reviewers, rotation, created_at
+		FROM assignment_records
+		WHERE pull_request_id = $1
+		ORDER BY id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []models.AssignmentRecord{}
+	for rows.Next() {
+		var record models.AssignmentRecord
+		var inputs []byte
+		if err := rows.Scan(&record.ID, &record.PullRequestID, &record.TeamName, &record.AssignedBy, &record.Strategy,
+			&record.Seed, &inputs, pq.Array(&record.Reviewers), &record.Rotation, &record.CreatedAt); err != nil {
+			return nil, err
+		}
+		record.Inputs = inputs
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
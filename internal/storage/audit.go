@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const defaultAuditLimit = 100
+
+func newAudit(action, entityType, entityID string, before, after interface{}) models.AuditEvent {
+	return models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+	}
+}
+
+func prAudit(action, prID, teamName string, userIDs []string, before, after interface{}) models.AuditEvent {
+	event := newAudit(action, models.EntityPullRequest, prID, before, after)
+	event.PullRequestID = prID
+	event.TeamName = teamName
+	event.UserIDs = userIDs
+	return event
+}
+
+func auditJSON(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func auditLimit(limit int) int {
+	if limit <= 0 || limit > defaultAuditLimit {
+		return defaultAuditLimit
+	}
+	return limit
+}
+
+// WithActor returns a view of the storage whose changes are recorded in the
+// audit log as made by actor.
+func (s *Storage) WithActor(actor string) Store {
+	view := *s
+	view.actor = actor
+	return &view
+}
+
+func (s *Storage) currentActor() string {
+	if s.actor == "" {
+		return models.DefaultActor
+	}
+	return s.actor
+}
+
+// writeAudit appends to audit_events. It runs on the transaction of the change
+// it records, so the log never disagrees with the data.
+func (s *Storage) writeAudit(ctx context.Context, q querier, event models.AuditEvent) error {
+	if event.UserIDs == nil {
+		event.UserIDs = []string{}
+	}
+
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO audit_events (actor, action, entity_type, entity_id, pull_request_id, team_name, user_ids, before, after)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9)
+	`, s.currentActor(), event.Action, event.EntityType, event.EntityID, event.PullRequestID, event.TeamName,
+		pq.Array(event.UserIDs), nullJSON(event.Before), nullJSON(event.After))
+	return err
+}
+
+func nullJSON(data json.RawMessage) interface{} {
+	if data == nil {
+		return nil
+	}
+	return []byte(data)
+}
+
+func userTeam(ctx context.Context, q querier, userID string) (string, error) {
+	var teamName string
+	err := q.QueryRowContext(ctx, `SELECT team_name FROM users WHERE user_id = $1`, userID).Scan(&teamName)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	return teamName, nil
+}
+
+func (s *Storage) auditReviewerChange(ctx context.Context, q querier, prID, authorID string, before, after []string) error {
+	teamName, err := userTeam(ctx, q, authorID)
+	if err != nil {
+		return err
+	}
+
+	audit := prAudit(models.AuditPRReviewersUpdated, prID, teamName, reviewerDiff(before, after),
+		map[string][]string{"reviewers": before}, map[string][]string{"reviewers": after})
+	return s.writeAudit(ctx, q, audit)
+}
+
+// reviewerDiff lists the users that were added to or removed from a reviewer set.
+func reviewerDiff(before, after []string) []string {
+	var changed []string
+	for _, userID := range before {
+		if !containsString(after, userID) {
+			changed = append(changed, userID)
+		}
+	}
+	for _, userID := range after {
+		if !containsString(before, userID) {
+			changed = append(changed, userID)
+		}
+	}
+	return changed
+}
+
+func (s *Storage) ListAuditEvents(ctx context.Context, filter models.AuditFilter) (_ []models.AuditEvent, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	conditions := []string{"id > $1"}
+	args := []interface{}{filter.AfterID}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.PullRequestID != "" {
+		add("pull_request_id = $%d", filter.PullRequestID)
+	}
+	if filter.UserID != "" {
+		add("$%d = ANY(user_ids)", filter.UserID)
+	}
+	if filter.TeamName != "" {
+		add("team_name = $%d", filter.TeamName)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if !filter.From.IsZero() {
+		add("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		add("created_at < $%d", filter.To)
+	}
+	args = append(args, auditLimit(filter.Limit))
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT id, actor, action, entity_type, entity_id, COALESCE(pull_request_id, ''), COALESCE(team_name, ''),
+			user_ids, before, after, created_at
+		FROM audit_events
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY id
+		LIMIT $`+fmt.Sprint(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	auditEvents := []models.AuditEvent{}
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after []byte
+		err := rows.Scan(&event.ID, &event.Actor, &event.Action, &event.EntityType, &event.EntityID,
+			&event.PullRequestID, &event.TeamName, (*pq.StringArray)(&event.UserIDs), &before, &after, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if before != nil {
+			event.Before = before
+		}
+		if after != nil {
+			event.After = after
+		}
+		auditEvents = append(auditEvents, event)
+	}
+
+	return auditEvents, rows.Err()
+}
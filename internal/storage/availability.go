@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const availabilityColumns = "id, user_id, starts_at, ends_at, reason, reassign_reviews, reviews_reassigned_at"
+
+func availabilityFields(period *models.AvailabilityPeriod) []interface{} {
+	return []interface{}{&period.ID, &period.UserID, &period.Start, &period.End, &period.Reason,
+		&period.ReassignReviews, &period.ReviewsReassignedAt}
+}
+
+func scanAvailabilityPeriods(rows *sql.Rows) ([]models.AvailabilityPeriod, error) {
+	defer rows.Close()
+
+	periods := []models.AvailabilityPeriod{}
+	for rows.Next() {
+		var period models.AvailabilityPeriod
+		if err := rows.Scan(availabilityFields(&period)...); err != nil {
+			return nil, err
+		}
+		periods = append(periods, period)
+	}
+
+	return periods, rows.Err()
+}
+
+func (s *Storage) AddAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (_ *models.AvailabilityPeriod, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_availability (user_id, starts_at, ends_at, reason, reassign_reviews)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+availabilityColumns+`
+	`, period.UserID, period.Start, period.End, period.Reason, period.ReassignReviews).Scan(availabilityFields(&period)...)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(models.AuditAvailabilityAdded, models.EntityUser, period.UserID, nil, period)
+	audit.UserIDs = []string{period.UserID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	return &period, tx.Commit()
+}
+
+func (s *Storage) ListAvailabilityPeriods(ctx context.Context, userID string) (_ []models.AvailabilityPeriod, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+availabilityColumns+`
+		FROM user_availability
+		WHERE user_id = $1
+		ORDER BY starts_at, id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAvailabilityPeriods(rows)
+}
+
+func (s *Storage) UpdateAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (_ *models.AvailabilityPeriod, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var before models.AvailabilityPeriod
+	err = tx.QueryRowContext(ctx, `
+		SELECT `+availabilityColumns+` FROM user_availability WHERE id = $1 FOR UPDATE
+	`, period.ID).Scan(availabilityFields(&before)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated models.AvailabilityPeriod
+	err = tx.QueryRowContext(ctx, `
+		UPDATE user_availability
+		SET starts_at = $1, ends_at = $2, reason = $3, reassign_reviews = $4
+		WHERE id = $5
+		RETURNING `+availabilityColumns+`
+	`, period.Start, period.End, period.Reason, period.ReassignReviews, period.ID).Scan(availabilityFields(&updated)...)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(models.AuditAvailabilityUpdated, models.EntityUser, updated.UserID, before, updated)
+	audit.UserIDs = []string{updated.UserID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	return &updated, tx.Commit()
+}
+
+func (s *Storage) DeleteAvailabilityPeriod(ctx context.Context, id int64) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var period models.AvailabilityPeriod
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM user_availability WHERE id = $1
+		RETURNING `+availabilityColumns+`
+	`, id).Scan(availabilityFields(&period)...)
+	if err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditAvailabilityRemoved, models.EntityUser, period.UserID, period, nil)
+	audit.UserIDs = []string{period.UserID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) GetUnavailableUsers(ctx context.Context, userIDs []string, at time.Time) (_ map[string]models.AvailabilityPeriod, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return unavailableUsers(ctx, s.q(), userIDs, at)
+}
+
+// unavailableUsers picks, for each listed user away at the given time, the
+// period that ends last.
+func unavailableUsers(ctx context.Context, q querier, userIDs []string, at time.Time) (map[string]models.AvailabilityPeriod, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT DISTINCT ON (user_id) `+availabilityColumns+`
+		FROM user_availability
+		WHERE user_id = ANY($1) AND starts_at <= $2 AND ends_at > $2
+		ORDER BY user_id, ends_at DESC
+	`, pq.Array(userIDs), at)
+	if err != nil {
+		return nil, err
+	}
+
+	periods, err := scanAvailabilityPeriods(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	away := make(map[string]models.AvailabilityPeriod, len(periods))
+	for _, period := range periods {
+		away[period.UserID] = period
+	}
+	return away, nil
+}
+
+func (s *Storage) DueReviewHandoffs(ctx context.Context, at time.Time) (_ []models.AvailabilityPeriod, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+availabilityColumns+`
+		FROM user_availability
+		WHERE reassign_reviews AND reviews_reassigned_at IS NULL
+		AND starts_at <= $1 AND ends_at > $1
+		ORDER BY starts_at, id
+	`, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAvailabilityPeriods(rows)
+}
+
+func (s *Storage) MarkReviewsHandedOff(ctx context.Context, id int64, at time.Time) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	_, err = s.q().ExecContext(ctx, `
+		UPDATE user_availability SET reviews_reassigned_at = $1 WHERE id = $2
+	`, at, id)
+	return err
+}
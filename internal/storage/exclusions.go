@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+const exclusionColumns = "reviewer_id, author_id, reason, created_at"
+
+func exclusionFields(exclusion *models.ReviewerExclusion) []interface{} {
+	return []interface{}{&exclusion.ReviewerID, &exclusion.AuthorID, &exclusion.Reason, &exclusion.CreatedAt}
+}
+
+func scanExclusions(rows *sql.Rows) ([]models.ReviewerExclusion, error) {
+	defer rows.Close()
+
+	exclusions := []models.ReviewerExclusion{}
+	for rows.Next() {
+		var exclusion models.ReviewerExclusion
+		if err := rows.Scan(exclusionFields(&exclusion)...); err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+
+	return exclusions, rows.Err()
+}
+
+// exclusionID names an exclusion in the audit log.
+func exclusionID(reviewerID, authorID string) string {
+	return reviewerID + ":" + authorID
+}
+
+func (s *Storage) AddReviewerExclusion(ctx context.Context, exclusion models.ReviewerExclusion) (_ *models.ReviewerExclusion, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO reviewer_exclusions (reviewer_id, author_id, reason)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (reviewer_id, author_id) DO UPDATE SET reason = $3
+		RETURNING `+exclusionColumns+`
+	`, exclusion.ReviewerID, exclusion.AuthorID, exclusion.Reason).Scan(exclusionFields(&exclusion)...)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(models.AuditExclusionAdded, models.EntityExclusion, exclusionID(exclusion.ReviewerID, exclusion.AuthorID),
+		nil, exclusion)
+	audit.UserIDs = []string{exclusion.ReviewerID, exclusion.AuthorID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	return &exclusion, tx.Commit()
+}
+
+func (s *Storage) ListReviewerExclusions(ctx context.Context, userID string) (_ []models.ReviewerExclusion, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+exclusionColumns+`
+		FROM reviewer_exclusions
+		WHERE $1 = '' OR reviewer_id = $1 OR author_id = $1
+		ORDER BY created_at, reviewer_id, author_id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanExclusions(rows)
+}
+
+func (s *Storage) DeleteReviewerExclusion(ctx context.Context, reviewerID, authorID string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exclusion models.ReviewerExclusion
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM reviewer_exclusions WHERE reviewer_id = $1 AND author_id = $2
+		RETURNING `+exclusionColumns+`
+	`, reviewerID, authorID).Scan(exclusionFields(&exclusion)...)
+	if err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditExclusionRemoved, models.EntityExclusion, exclusionID(reviewerID, authorID), exclusion, nil)
+	audit.UserIDs = []string{reviewerID, authorID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) GetReviewerExclusions(ctx context.Context, authorID string, userIDs []string) (_ map[string]models.ReviewerExclusion, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return reviewerExclusions(ctx, s.q(), authorID, userIDs)
+}
+
+func reviewerExclusions(ctx context.Context, q querier, authorID string, userIDs []string) (map[string]models.ReviewerExclusion, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT `+exclusionColumns+`
+		FROM reviewer_exclusions
+		WHERE author_id = $1 AND reviewer_id = ANY($2)
+	`, authorID, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	exclusions, err := scanExclusions(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]models.ReviewerExclusion, len(exclusions))
+	for _, exclusion := range exclusions {
+		excluded[exclusion.ReviewerID] = exclusion
+	}
+	return excluded, nil
+}
+
+func (s *Storage) GetRepeatReviewers(ctx context.Context, authorID, skipPRID string, n int) (_ []string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return repeatReviewers(ctx, s.q(), authorID, skipPRID, n)
+}
+
+func repeatReviewers(ctx context.Context, q querier, authorID, skipPRID string, n int) ([]string, error) {
+	if n <= 0 {
+		return []string{}, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		WITH recent AS (
+			SELECT pr.pull_request_id
+			FROM pull_requests pr
+			WHERE pr.author_id = $1 AND pr.pull_request_id != $2
+			AND EXISTS (
+				SELECT 1 FROM pr_reviewers r
+				WHERE r.pull_request_id = pr.pull_request_id AND r.state = 'ASSIGNED'
+			)
+			ORDER BY pr.created_at DESC, pr.pull_request_id DESC
+			LIMIT $3
+		)
+		SELECT r.user_id
+		FROM pr_reviewers r
+		JOIN recent ON recent.pull_request_id = r.pull_request_id
+		WHERE r.state = 'ASSIGNED'
+		GROUP BY r.user_id
+		HAVING COUNT(DISTINCT r.pull_request_id) = $3
+		ORDER BY r.user_id
+	`, authorID, skipPRID, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := []string{}
+	for rows.Next() {
+		var reviewer string
+		if err := rows.Scan(&reviewer); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, reviewer)
+	}
+	return reviewers, rows.Err()
+}
@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"pr-reviewer-service/internal/models"
+)
+
+func (s *Storage) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, lease time.Duration) (_ *models.IdempotentResponse, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP + make_interval(secs => $3))
+		ON CONFLICT (key) DO NOTHING
+	`, key, requestHash, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	if claimed, _ := res.RowsAffected(); claimed > 0 {
+		return nil, tx.Commit()
+	}
+
+	var existing models.IdempotentResponse
+	var statusCode sql.NullInt64
+	var headers []byte
+	err = tx.QueryRowContext(ctx, `
+		SELECT key, request_hash, status_code, headers, body FROM idempotency_keys WHERE key = $1
+	`, key).Scan(&existing.Key, &existing.RequestHash, &statusCode, &headers, &existing.Body)
+	if err != nil {
+		return nil, err
+	}
+	existing.StatusCode = int(statusCode.Int64)
+	if headers != nil {
+		if err := json.Unmarshal(headers, &existing.Headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return &existing, tx.Commit()
+}
+
+func (s *Storage) SaveIdempotentResponse(ctx context.Context, response models.IdempotentResponse, ttl time.Duration) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	headers, err := json.Marshal(response.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.q().ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $2, headers = $3, body = $4, expires_at = CURRENT_TIMESTAMP + make_interval(secs => $5)
+		WHERE key = $1
+	`, response.Key, response.StatusCode, headers, response.Body, ttl.Seconds())
+	return err
+}
+
+func (s *Storage) ReleaseIdempotencyKey(ctx context.Context, key string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	_, err = s.q().ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key)
+	return err
+}
@@ -0,0 +1,1530 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/models"
+)
+
+type MemoryStorage struct {
+	*memState
+	actor string
+	inTx  bool
+}
+
+// memState is shared by all actor views of one MemoryStorage.
+type memState struct {
+	mu sync.RWMutex
+	memData
+}
+
+// memData is everything the store holds; InTx copies it to roll back.
+type memData struct {
+	teams      map[string]models.TeamSettings
+	users      map[string]models.User
+	userOrder  []string
+	identities map[memIdentity]string
+	prs        map[string]models.PullRequest
+	prOrder    []string
+	reviewers  map[string][]memAssignment
+
+	subscriptions []models.WebhookSubscription
+	deadLetters   []models.DeadLetter
+	nextID        int64
+
+	availability []models.AvailabilityPeriod
+	exclusions   []models.ReviewerExclusion
+	assignments  []models.AssignmentRecord
+
+	outbox []memOutboxEntry
+	audit  []models.AuditEvent
+
+	idempotency map[string]memIdempotentResponse
+}
+
+type memIdempotentResponse struct {
+	models.IdempotentResponse
+	expiresAt time.Time
+}
+
+type memOutboxEntry struct {
+	events.PendingEvent
+	claimedBy    string
+	claimedUntil time.Time
+}
+
+type memIdentity struct {
+	provider string
+	login    string
+}
+
+type memAssignment struct {
+	models.ReviewerAssignment
+	review *models.Review
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{memState: &memState{memData: memData{
+		teams:      make(map[string]models.TeamSettings),
+		users:      make(map[string]models.User),
+		identities: make(map[memIdentity]string),
+		prs:        make(map[string]models.PullRequest),
+		reviewers:  make(map[string][]memAssignment),
+
+		idempotency: make(map[string]memIdempotentResponse),
+	}}}
+}
+
+// lock takes the write lock, unless this view runs inside InTx and already
+// holds it.
+func (m *MemoryStorage) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (m *MemoryStorage) rlock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+func memNow() *string {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	return &now
+}
+
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	return append(make([]string, 0, len(values)), values...)
+}
+
+func copyLimit(limit *int) *int {
+	if limit == nil {
+		return nil
+	}
+	value := *limit
+	return &value
+}
+
+func copySettings(settings models.TeamSettings) models.TeamSettings {
+	settings.FallbackTeams = append([]string{}, settings.FallbackTeams...)
+	rules := make([]models.OwnershipRule, len(settings.OwnershipRules))
+	for i, rule := range settings.OwnershipRules {
+		rules[i] = models.OwnershipRule{Pattern: rule.Pattern, Owners: append([]string{}, rule.Owners...)}
+	}
+	settings.OwnershipRules = rules
+	return settings
+}
+
+func copySkills(skills map[string]int) map[string]int {
+	if len(skills) == 0 {
+		return nil
+	}
+	copied := make(map[string]int, len(skills))
+	for tag, level := range skills {
+		copied[tag] = level
+	}
+	return copied
+}
+
+func copyPR(pr models.PullRequest) models.PullRequest {
+	pr.AssignedReviewers = append([]string{}, pr.AssignedReviewers...)
+	pr.ChangedFiles = copyStrings(pr.ChangedFiles)
+	pr.RequiredTags = copyStrings(pr.RequiredTags)
+	return pr
+}
+
+func (m *MemoryStorage) CreateTeam(ctx context.Context, team models.Team) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if _, ok := m.teams[team.TeamName]; !ok {
+		settings := assignment.DefaultSettings(team.TeamName)
+		if team.Settings != nil {
+			settings = *team.Settings
+		}
+		settings.Version = 1
+		m.teams[team.TeamName] = copySettings(settings)
+
+		audit := newAudit(models.AuditTeamCreated, models.EntityTeam, team.TeamName, nil, settings)
+		audit.TeamName = team.TeamName
+		m.record(audit)
+	}
+
+	for _, member := range team.Members {
+		var before *models.User
+		if existing, ok := m.users[member.UserID]; ok {
+			before = &existing
+			if existing.TeamName != team.TeamName {
+				m.bumpTeamVersion(existing.TeamName)
+			}
+		} else {
+			m.userOrder = append(m.userOrder, member.UserID)
+		}
+		// Readers hold the stored map, so it is copied rather than changed.
+		logins := maps.Clone(m.users[member.UserID].Logins)
+		for provider, login := range member.Logins {
+			if logins == nil {
+				logins = make(map[string]string)
+			}
+			if previous, ok := logins[provider]; ok {
+				delete(m.identities, memIdentity{provider, previous})
+			}
+			logins[provider] = login
+			m.identities[memIdentity{provider, login}] = member.UserID
+		}
+		m.users[member.UserID] = models.User{
+			UserID:         member.UserID,
+			Username:       member.Username,
+			TeamName:       team.TeamName,
+			IsActive:       member.IsActive,
+			MaxOpenReviews: copyLimit(member.MaxOpenReviews),
+			Skills:         copySkills(member.Skills),
+			Role:           member.Role,
+			Logins:         logins,
+		}
+
+		after := member
+		after.TeamName = team.TeamName
+		audit := newAudit(models.AuditUserSaved, models.EntityUser, member.UserID, before, after)
+		audit.TeamName = team.TeamName
+		audit.UserIDs = []string{member.UserID}
+		m.record(audit)
+	}
+
+	return nil
+}
+
+func (m *MemoryStorage) GetTeam(ctx context.Context, teamName string) (*models.Team, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	settings, ok := m.teams[teamName]
+	if !ok {
+		return nil, nil
+	}
+	settings = copySettings(settings)
+
+	var members []models.User
+	for _, id := range m.userOrder {
+		if user := m.users[id]; user.TeamName == teamName {
+			members = append(members, user)
+		}
+	}
+
+	return &models.Team{
+		TeamName: teamName,
+		Settings: &settings,
+		Members:  members,
+	}, nil
+}
+
+func (m *MemoryStorage) GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	settings, ok := m.teams[teamName]
+	if !ok {
+		return nil, nil
+	}
+	settings = copySettings(settings)
+	return &settings, nil
+}
+
+func (m *MemoryStorage) GetTeamSettingsForUpdate(ctx context.Context, teamName string) (*models.TeamSettings, error) {
+	return m.GetTeamSettings(ctx, teamName)
+}
+
+func (m *MemoryStorage) UpdateTeamSettings(ctx context.Context, settings models.TeamSettings) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if before, ok := m.teams[settings.TeamName]; ok {
+		settings.Version = before.Version + 1
+		m.teams[settings.TeamName] = copySettings(settings)
+
+		audit := newAudit(models.AuditTeamSettingsUpdated, models.EntityTeam, settings.TeamName, before, settings)
+		audit.TeamName = settings.TeamName
+		m.record(audit)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) GetUserByID(ctx context.Context, userID string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (m *MemoryStorage) GetUserByLogin(ctx context.Context, provider, login string) (*models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	userID, ok := m.identities[memIdentity{provider, login}]
+	if !ok {
+		return nil, nil
+	}
+	user := m.users[userID]
+	return &user, nil
+}
+
+func (m *MemoryStorage) GetUserTeam(ctx context.Context, userID string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	defer m.rlock()()
+
+	user, ok := m.users[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return user.TeamName, nil
+}
+
+func (m *MemoryStorage) UpdateUserActive(ctx context.Context, userID string, isActive bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if user, ok := m.users[userID]; ok && user.IsActive != isActive {
+		audit := newAudit(models.AuditUserActiveChanged, models.EntityUser, userID,
+			map[string]bool{"is_active": user.IsActive}, map[string]bool{"is_active": isActive})
+		audit.TeamName = user.TeamName
+		audit.UserIDs = []string{userID}
+		m.record(audit)
+
+		user.IsActive = isActive
+		m.users[userID] = user
+		m.bumpTeamVersion(user.TeamName)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) bumpTeamVersion(teamName string) {
+	if settings, ok := m.teams[teamName]; ok {
+		settings.Version++
+		m.teams[teamName] = settings
+	}
+}
+
+func (m *MemoryStorage) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	var users []models.User
+	for _, id := range m.userOrder {
+		user := m.users[id]
+		if user.TeamName == teamName && user.IsActive && user.UserID != excludeUserID {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (m *MemoryStorage) GetActiveUsersOutsideTeam(ctx context.Context, teamName, excludeUserID string) ([]models.User, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	var users []models.User
+	for _, id := range m.userOrder {
+		user := m.users[id]
+		if user.TeamName != teamName && user.IsActive && user.UserID != excludeUserID {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (m *MemoryStorage) GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	counts := make(map[string]int, len(userIDs))
+	for _, userID := range userIDs {
+		if _, ok := m.users[userID]; ok {
+			counts[userID] = m.openReviewCount(userID)
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryStorage) GetReviewLimits(ctx context.Context, userIDs []string) (map[string]int, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	limits := make(map[string]int)
+	for _, userID := range userIDs {
+		if limit := m.reviewLimit(userID); limit != nil {
+			limits[userID] = *limit
+		}
+	}
+	return limits, nil
+}
+
+// reviewLimit is the user's own limit, else the team default; a team default
+// of 0 means no limit.
+func (m *MemoryStorage) reviewLimit(userID string) *int {
+	user, ok := m.users[userID]
+	if !ok {
+		return nil
+	}
+	if user.MaxOpenReviews != nil {
+		return copyLimit(user.MaxOpenReviews)
+	}
+	if settings, ok := m.teams[user.TeamName]; ok && settings.MaxOpenReviews > 0 {
+		return copyLimit(&settings.MaxOpenReviews)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) SetUserMaxOpenReviews(ctx context.Context, userID string, limit *int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if user, ok := m.users[userID]; ok {
+		audit := newAudit(models.AuditUserReviewLimitChanged, models.EntityUser, userID,
+			map[string]*int{"max_open_reviews": user.MaxOpenReviews}, map[string]*int{"max_open_reviews": limit})
+		audit.TeamName = user.TeamName
+		audit.UserIDs = []string{userID}
+		m.record(audit)
+
+		user.MaxOpenReviews = copyLimit(limit)
+		m.users[userID] = user
+		m.bumpTeamVersion(user.TeamName)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) SetUserRole(ctx context.Context, userID, role string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if user, ok := m.users[userID]; ok {
+		audit := newAudit(models.AuditUserRoleChanged, models.EntityUser, userID,
+			map[string]string{"role": user.Role}, map[string]string{"role": role})
+		audit.TeamName = user.TeamName
+		audit.UserIDs = []string{userID}
+		m.record(audit)
+
+		user.Role = role
+		m.users[userID] = user
+		m.bumpTeamVersion(user.TeamName)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) AddAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (*models.AvailabilityPeriod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	m.nextID++
+	period.ID = m.nextID
+	period.ReviewsReassignedAt = nil
+	m.availability = append(m.availability, period)
+
+	audit := newAudit(models.AuditAvailabilityAdded, models.EntityUser, period.UserID, nil, period)
+	audit.UserIDs = []string{period.UserID}
+	m.record(audit)
+	return &period, nil
+}
+
+func (m *MemoryStorage) ListAvailabilityPeriods(ctx context.Context, userID string) ([]models.AvailabilityPeriod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	periods := []models.AvailabilityPeriod{}
+	for _, period := range m.availability {
+		if period.UserID == userID {
+			periods = append(periods, period)
+		}
+	}
+	sort.SliceStable(periods, func(i, j int) bool { return periods[i].Start.Before(periods[j].Start) })
+	return periods, nil
+}
+
+func (m *MemoryStorage) UpdateAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (*models.AvailabilityPeriod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	for i, before := range m.availability {
+		if before.ID == period.ID {
+			updated := before
+			updated.Start, updated.End = period.Start, period.End
+			updated.Reason, updated.ReassignReviews = period.Reason, period.ReassignReviews
+			m.availability[i] = updated
+
+			audit := newAudit(models.AuditAvailabilityUpdated, models.EntityUser, updated.UserID, before, updated)
+			audit.UserIDs = []string{updated.UserID}
+			m.record(audit)
+			return &updated, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *MemoryStorage) DeleteAvailabilityPeriod(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, period := range m.availability {
+		if period.ID == id {
+			m.availability = append(m.availability[:i], m.availability[i+1:]...)
+
+			audit := newAudit(models.AuditAvailabilityRemoved, models.EntityUser, period.UserID, period, nil)
+			audit.UserIDs = []string{period.UserID}
+			m.record(audit)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MemoryStorage) GetUnavailableUsers(ctx context.Context, userIDs []string, at time.Time) (map[string]models.AvailabilityPeriod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	away := make(map[string]models.AvailabilityPeriod)
+	for _, userID := range userIDs {
+		if period := m.awayPeriod(userID, at); period != nil {
+			away[userID] = *period
+		}
+	}
+	return away, nil
+}
+
+// awayPeriod returns the period userID is away on at the given time, picking
+// the one that ends last when several overlap.
+func (m *MemoryStorage) awayPeriod(userID string, at time.Time) *models.AvailabilityPeriod {
+	var away *models.AvailabilityPeriod
+	for i, period := range m.availability {
+		if period.UserID == userID && period.Covers(at) && (away == nil || period.End.After(away.End)) {
+			away = &m.availability[i]
+		}
+	}
+	if away == nil {
+		return nil
+	}
+	period := *away
+	return &period
+}
+
+func (m *MemoryStorage) DueReviewHandoffs(ctx context.Context, at time.Time) ([]models.AvailabilityPeriod, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	due := []models.AvailabilityPeriod{}
+	for _, period := range m.availability {
+		if period.ReassignReviews && period.ReviewsReassignedAt == nil && period.Covers(at) {
+			due = append(due, period)
+		}
+	}
+	return due, nil
+}
+
+func (m *MemoryStorage) MarkReviewsHandedOff(ctx context.Context, id int64, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, period := range m.availability {
+		if period.ID == id {
+			m.availability[i].ReviewsReassignedAt = &at
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) AddReviewerExclusion(ctx context.Context, exclusion models.ReviewerExclusion) (*models.ReviewerExclusion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	id := exclusionID(exclusion.ReviewerID, exclusion.AuthorID)
+	stored := false
+	for i, existing := range m.exclusions {
+		if existing.ReviewerID == exclusion.ReviewerID && existing.AuthorID == exclusion.AuthorID {
+			m.exclusions[i].Reason = exclusion.Reason
+			exclusion = m.exclusions[i]
+			stored = true
+		}
+	}
+	if !stored {
+		exclusion.CreatedAt = time.Now()
+		m.exclusions = append(m.exclusions, exclusion)
+	}
+
+	audit := newAudit(models.AuditExclusionAdded, models.EntityExclusion, id, nil, exclusion)
+	audit.UserIDs = []string{exclusion.ReviewerID, exclusion.AuthorID}
+	m.record(audit)
+	return &exclusion, nil
+}
+
+func (m *MemoryStorage) ListReviewerExclusions(ctx context.Context, userID string) ([]models.ReviewerExclusion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	exclusions := []models.ReviewerExclusion{}
+	for _, exclusion := range m.exclusions {
+		if userID == "" || exclusion.ReviewerID == userID || exclusion.AuthorID == userID {
+			exclusions = append(exclusions, exclusion)
+		}
+	}
+	return exclusions, nil
+}
+
+func (m *MemoryStorage) DeleteReviewerExclusion(ctx context.Context, reviewerID, authorID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, exclusion := range m.exclusions {
+		if exclusion.ReviewerID == reviewerID && exclusion.AuthorID == authorID {
+			m.exclusions = append(m.exclusions[:i], m.exclusions[i+1:]...)
+
+			audit := newAudit(models.AuditExclusionRemoved, models.EntityExclusion, exclusionID(reviewerID, authorID), exclusion, nil)
+			audit.UserIDs = []string{reviewerID, authorID}
+			m.record(audit)
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MemoryStorage) GetReviewerExclusions(ctx context.Context, authorID string, userIDs []string) (map[string]models.ReviewerExclusion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	excluded := make(map[string]models.ReviewerExclusion)
+	for _, userID := range userIDs {
+		if exclusion := m.exclusion(userID, authorID); exclusion != nil {
+			excluded[userID] = *exclusion
+		}
+	}
+	return excluded, nil
+}
+
+func (m *MemoryStorage) exclusion(reviewerID, authorID string) *models.ReviewerExclusion {
+	for _, exclusion := range m.exclusions {
+		if exclusion.ReviewerID == reviewerID && exclusion.AuthorID == authorID {
+			return &exclusion
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) GetRepeatReviewers(ctx context.Context, authorID, skipPRID string, n int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	return m.repeatReviewers(authorID, skipPRID, n), nil
+}
+
+func (m *MemoryStorage) repeatReviewers(authorID, skipPRID string, n int) []string {
+	var recent []models.PullRequest
+	for i := len(m.prOrder) - 1; i >= 0 && len(recent) < n; i-- {
+		pr := m.prs[m.prOrder[i]]
+		if pr.AuthorID == authorID && pr.PullRequestID != skipPRID && len(pr.AssignedReviewers) > 0 {
+			recent = append(recent, pr)
+		}
+	}
+
+	reviewers := []string{}
+	if n <= 0 || len(recent) < n {
+		return reviewers
+	}
+	for _, reviewer := range recent[0].AssignedReviewers {
+		inAll := true
+		for _, pr := range recent[1:] {
+			inAll = inAll && containsString(pr.AssignedReviewers, reviewer)
+		}
+		if inAll {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	sort.Strings(reviewers)
+	return reviewers
+}
+
+func (m *MemoryStorage) openReviewCount(userID string) int {
+	count := 0
+	for _, pr := range m.prs {
+		if models.IsActiveStatus(pr.Status) && containsString(pr.AssignedReviewers, userID) {
+			count++
+		}
+	}
+	return count
+}
+
+func (m *MemoryStorage) CreatePR(ctx context.Context, pr models.PullRequest) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if _, ok := m.prs[pr.PullRequestID]; ok {
+		return ErrPRExists
+	}
+
+	pr = copyPR(pr)
+	if pr.Status == "" {
+		pr.Status = models.StatusOpen
+	}
+	pr.CreatedAt = memNow()
+	pr.MergedAt = nil
+	pr.ClosedAt = nil
+	pr.Version = 1
+
+	m.prs[pr.PullRequestID] = pr
+	m.prOrder = append(m.prOrder, pr.PullRequestID)
+	m.emit(prCreatedEvent(pr))
+	for _, reviewer := range pr.AssignedReviewers {
+		m.assignReviewer(pr.PullRequestID, reviewer, models.AssignedByCreate)
+	}
+
+	userIDs := append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+	m.record(prAudit(models.AuditPRCreated, pr.PullRequestID, m.users[pr.AuthorID].TeamName, userIDs, nil, pr))
+	return nil
+}
+
+func (m *MemoryStorage) GetPRByID(ctx context.Context, prID string) (*models.PullRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	pr, ok := m.prs[prID]
+	if !ok {
+		return nil, nil
+	}
+	pr = copyPR(pr)
+	pr.Reviews = m.reviews(pr)
+	return &pr, nil
+}
+
+func (m *MemoryStorage) GetPRForUpdate(ctx context.Context, prID string) (*models.PullRequest, error) {
+	return m.GetPRByID(ctx, prID)
+}
+
+func (m *MemoryStorage) UpdatePRStatus(ctx context.Context, prID string, status string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	pr, ok := m.prs[prID]
+	if !ok {
+		return nil
+	}
+
+	if pr.Status == status {
+		return nil
+	}
+	if !models.CanTransition(pr.Status, status) {
+		return ErrInvalidTransition
+	}
+
+	m.record(prAudit(models.AuditPRStatusChanged, prID, m.users[pr.AuthorID].TeamName, []string{pr.AuthorID},
+		map[string]string{"status": pr.Status}, map[string]string{"status": status}))
+
+	switch status {
+	case models.StatusMerged:
+		pr.MergedAt = memNow()
+	case models.StatusClosed:
+		pr.ClosedAt = memNow()
+	case models.StatusReopened:
+		pr.ClosedAt = nil
+	}
+	pr.Status = status
+	pr.Version++
+	m.prs[prID] = pr
+	if status == models.StatusMerged {
+		m.emit(prMergedEvent(prID))
+	}
+	return nil
+}
+
+func (m *MemoryStorage) UpdatePRReviewers(ctx context.Context, prID string, reviewers []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	pr, ok := m.prs[prID]
+	if !ok {
+		return nil
+	}
+
+	for _, reviewer := range pr.AssignedReviewers {
+		if !containsString(reviewers, reviewer) {
+			m.releaseReviewer(prID, reviewer, models.ReviewerStateRemoved, nil)
+		}
+	}
+	for _, reviewer := range reviewers {
+		if !containsString(pr.AssignedReviewers, reviewer) {
+			m.assignReviewer(prID, reviewer, models.AssignedByUpdate)
+		}
+	}
+
+	m.auditReviewerChange(pr, pr.AssignedReviewers, reviewers)
+	pr.AssignedReviewers = copyStrings(reviewers)
+	pr.Version++
+	m.prs[prID] = pr
+	return nil
+}
+
+func (m *MemoryStorage) ReplacePRReviewer(ctx context.Context, prID, oldUserID, newUserID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	pr, ok := m.prs[prID]
+	if !ok || !containsString(pr.AssignedReviewers, oldUserID) {
+		return sql.ErrNoRows
+	}
+
+	m.replaceReviewer(&pr, oldUserID, newUserID, models.AssignedByReassign)
+	pr.Version++
+	m.prs[prID] = pr
+
+	m.record(prAudit(models.AuditReviewerReplaced, prID, m.users[pr.AuthorID].TeamName, []string{oldUserID, newUserID},
+		map[string]string{"reviewer_id": oldUserID}, map[string]string{"reviewer_id": newUserID}))
+	return nil
+}
+
+func (m *MemoryStorage) GetPRReviewerHistory(ctx context.Context, prID string) ([]models.ReviewerAssignment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	history := []models.ReviewerAssignment{}
+	for _, assignment := range m.reviewers[prID] {
+		entry := assignment.ReviewerAssignment
+		if entry.ReplacedBy != nil {
+			replacedBy := *entry.ReplacedBy
+			entry.ReplacedBy = &replacedBy
+		}
+		if assignment.review != nil {
+			verdict := assignment.review.Verdict
+			entry.Verdict = &verdict
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+func (m *MemoryStorage) AddAssignmentRecord(ctx context.Context, record models.AssignmentRecord) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	m.addAssignmentRecord(record)
+	return nil
+}
+
+func (m *MemoryStorage) addAssignmentRecord(record models.AssignmentRecord) {
+	m.nextID++
+	record.ID = m.nextID
+	record.Inputs = append(json.RawMessage{}, record.Inputs...)
+	record.Reviewers = copyStrings(record.Reviewers)
+	record.CreatedAt = time.Now()
+	m.assignments = append(m.assignments, record)
+}
+
+func (m *MemoryStorage) TeamRotation(ctx context.Context, teamName string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	defer m.rlock()()
+
+	for i := len(m.assignments) - 1; i >= 0; i-- {
+		if m.assignments[i].TeamName == teamName {
+			return m.assignments[i].Rotation, nil
+		}
+	}
+	return "", nil
+}
+
+func (m *MemoryStorage) TeamRotationForUpdate(ctx context.Context, teamName string) (string, error) {
+	return m.TeamRotation(ctx, teamName)
+}
+
+func (m *MemoryStorage) ListAssignmentRecords(ctx context.Context, prID string) ([]models.AssignmentRecord, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	records := []models.AssignmentRecord{}
+	for _, record := range m.assignments {
+		if record.PullRequestID == prID {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (m *MemoryStorage) SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict, comment string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, assignment := range m.reviewers[prID] {
+		if assignment.UserID == reviewerID && assignment.State == models.ReviewerStateAssigned {
+			var previous interface{}
+			if assignment.review != nil {
+				previous = map[string]string{"verdict": assignment.review.Verdict, "comment": assignment.review.Comment}
+			}
+			pr := m.prs[prID]
+			m.record(prAudit(models.AuditReviewSubmitted, prID, m.users[pr.AuthorID].TeamName, []string{reviewerID},
+				previous, map[string]string{"verdict": verdict, "comment": comment}))
+			pr.Version++
+			m.prs[prID] = pr
+
+			m.reviewers[prID][i].review = &models.Review{
+				ReviewerID: reviewerID,
+				Verdict:    verdict,
+				Comment:    comment,
+				ReviewedAt: memNow(),
+			}
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MemoryStorage) reviews(pr models.PullRequest) []models.Review {
+	var reviews []models.Review
+	for _, reviewer := range pr.AssignedReviewers {
+		for _, assignment := range m.reviewers[pr.PullRequestID] {
+			if assignment.UserID == reviewer && assignment.State == models.ReviewerStateAssigned && assignment.review != nil {
+				reviews = append(reviews, *assignment.review)
+			}
+		}
+	}
+	return reviews
+}
+
+func (m *MemoryStorage) assignReviewer(prID, userID, assignedBy string) {
+	m.recordAssignment(prID, userID, assignedBy)
+	m.emit(reviewerAssignedEvent(prID, userID, assignedBy))
+}
+
+func (m *MemoryStorage) recordAssignment(prID, userID, assignedBy string) {
+	var sourceTeam *string
+	if user, ok := m.users[userID]; ok {
+		sourceTeam = &user.TeamName
+	}
+	m.reviewers[prID] = append(m.reviewers[prID], memAssignment{
+		ReviewerAssignment: models.ReviewerAssignment{
+			UserID:     userID,
+			AssignedAt: memNow(),
+			AssignedBy: assignedBy,
+			SourceTeam: sourceTeam,
+			State:      models.ReviewerStateAssigned,
+		},
+	})
+}
+
+func (m *MemoryStorage) releaseReviewer(prID, userID, state string, replacedBy *string) {
+	history := m.reviewers[prID]
+	for i := range history {
+		if history[i].UserID == userID && history[i].State == models.ReviewerStateAssigned {
+			history[i].State = state
+			history[i].ReplacedBy = replacedBy
+		}
+	}
+}
+
+func (m *MemoryStorage) replaceReviewer(pr *models.PullRequest, oldUserID, newUserID, assignedBy string) {
+	for i, reviewer := range pr.AssignedReviewers {
+		if reviewer == oldUserID {
+			pr.AssignedReviewers[i] = newUserID
+		}
+	}
+	m.releaseReviewer(pr.PullRequestID, oldUserID, models.ReviewerStateReplaced, &newUserID)
+	m.recordAssignment(pr.PullRequestID, newUserID, assignedBy)
+	m.emit(reviewerReplacedEvent(pr.PullRequestID, oldUserID, newUserID, assignedBy))
+}
+
+func (m *MemoryStorage) GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequest, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	var prs []models.PullRequest
+	for i := len(m.prOrder) - 1; i >= 0; i-- {
+		pr := m.prs[m.prOrder[i]]
+		if containsString(pr.AssignedReviewers, userID) {
+			prs = append(prs, copyPR(pr))
+		}
+	}
+	return prs, nil
+}
+
+func (m *MemoryStorage) GetReviewStats(ctx context.Context) (map[string]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	stats := make(map[string]interface{})
+
+	var userStats []userStat
+	for _, id := range m.userOrder {
+		user := m.users[id]
+		stat := userStat{UserID: user.UserID, Username: user.Username}
+		for _, pr := range m.prs {
+			if containsString(pr.AssignedReviewers, user.UserID) {
+				stat.Count++
+			}
+		}
+		userStats = append(userStats, stat)
+	}
+	sort.SliceStable(userStats, func(i, j int) bool {
+		return userStats[i].Count > userStats[j].Count
+	})
+
+	var openPRs, mergedPRs, draftPRs, closedPRs int
+	for _, pr := range m.prs {
+		switch pr.Status {
+		case models.StatusOpen, models.StatusReopened:
+			openPRs++
+		case models.StatusMerged:
+			mergedPRs++
+		case models.StatusDraft:
+			draftPRs++
+		case models.StatusClosed:
+			closedPRs++
+		}
+	}
+
+	stats["user_assignments"] = userStats
+	stats["total_prs"] = len(m.prs)
+	stats["open_prs"] = openPRs
+	stats["merged_prs"] = mergedPRs
+	stats["draft_prs"] = draftPRs
+	stats["closed_prs"] = closedPRs
+	stats["total_users"] = len(userStats)
+
+	return stats, nil
+}
+
+func (m *MemoryStorage) DeactivateTeamUsers(ctx context.Context, teamName string) ([]string, []string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	defer m.lock()()
+
+	var deactivated []string
+	for _, id := range m.userOrder {
+		user := m.users[id]
+		if user.TeamName == teamName {
+			user.IsActive = false
+			m.users[id] = user
+			deactivated = append(deactivated, id)
+		}
+	}
+	if len(deactivated) > 0 {
+		m.bumpTeamVersion(teamName)
+	}
+
+	var affectedPRs []string
+	for _, prID := range m.prOrder {
+		pr := m.prs[prID]
+		if !models.IsActiveStatus(pr.Status) {
+			continue
+		}
+		for _, reviewer := range pr.AssignedReviewers {
+			if user, ok := m.users[reviewer]; ok && user.TeamName == teamName && !user.IsActive {
+				affectedPRs = append(affectedPRs, prID)
+				break
+			}
+		}
+	}
+	sort.Strings(affectedPRs)
+	return deactivated, affectedPRs, nil
+}
+
+func (m *MemoryStorage) ReplaceDeactivatedReviewers(ctx context.Context, prID string, removed, added []string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	pr, ok := m.prs[prID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	before := copyStrings(pr.AssignedReviewers)
+
+	for i, reviewer := range removed {
+		if i < len(added) {
+			m.replaceReviewer(&pr, reviewer, added[i], models.AssignedByBulkDeactivate)
+		} else {
+			m.releaseReviewer(prID, reviewer, models.ReviewerStateRemoved, nil)
+		}
+	}
+
+	var remaining []string
+	for _, reviewer := range pr.AssignedReviewers {
+		if !containsString(removed[min(len(added), len(removed)):], reviewer) {
+			remaining = append(remaining, reviewer)
+		}
+	}
+	if len(added) > len(removed) {
+		for _, reviewer := range added[len(removed):] {
+			m.assignReviewer(prID, reviewer, models.AssignedByBulkDeactivate)
+			remaining = append(remaining, reviewer)
+		}
+	}
+
+	m.auditReviewerChange(pr, before, remaining)
+	pr.AssignedReviewers = remaining
+	pr.Version++
+	m.prs[prID] = pr
+	return nil
+}
+
+func (m *MemoryStorage) RecordTeamDeactivated(ctx context.Context, teamName string, deactivated []string, result map[string]interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	m.emit(teamDeactivatedEvent(teamName, result))
+
+	audit := newAudit(models.AuditTeamBulkDeactivated, models.EntityTeam, teamName, nil, result)
+	audit.TeamName = teamName
+	audit.UserIDs = deactivated
+	m.record(audit)
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MemoryStorage) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	m.nextID++
+	sub.ID = m.nextID
+	sub.Events = append([]string{}, sub.Events...)
+	sub.CreatedAt = memNow()
+	m.subscriptions = append(m.subscriptions, sub)
+
+	m.record(newAudit(models.AuditSubscriptionCreated, models.EntitySubscription, strconv.FormatInt(sub.ID, 10), nil,
+		redactSubscription(sub)))
+	return &sub, nil
+}
+
+func (m *MemoryStorage) ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	subs := make([]models.WebhookSubscription, len(m.subscriptions))
+	for i, sub := range m.subscriptions {
+		sub.Events = copyStrings(sub.Events)
+		subs[i] = sub
+	}
+	return subs, nil
+}
+
+func (m *MemoryStorage) DeleteWebhookSubscription(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, sub := range m.subscriptions {
+		if sub.ID == id {
+			m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
+			m.record(newAudit(models.AuditSubscriptionDeleted, models.EntitySubscription, strconv.FormatInt(id, 10),
+				redactSubscription(sub), nil))
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (m *MemoryStorage) AddDeadLetter(ctx context.Context, letter models.DeadLetter) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	m.nextID++
+	letter.ID = m.nextID
+	letter.FailedAt = memNow()
+	m.deadLetters = append(m.deadLetters, letter)
+	return nil
+}
+
+func (m *MemoryStorage) ListDeadLetters(ctx context.Context) ([]models.DeadLetter, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	return append([]models.DeadLetter{}, m.deadLetters...), nil
+}
+
+// emit appends an event to the outbox; published events are dropped from it. Callers hold the write lock, so the
+// event becomes visible together with the change it describes.
+func (m *MemoryStorage) emit(event events.Event) {
+	m.outbox = append(m.outbox, memOutboxEntry{PendingEvent: events.PendingEvent{Event: event}})
+}
+
+func (m *MemoryStorage) PendingEvents(ctx context.Context, owner string, limit int, lease time.Duration) ([]events.PendingEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	now := time.Now()
+	var pending []events.PendingEvent
+	for i := range m.outbox {
+		if len(pending) == limit {
+			break
+		}
+		entry := &m.outbox[i]
+		if entry.claimedBy != "" && entry.claimedBy != owner && now.Before(entry.claimedUntil) {
+			continue
+		}
+		entry.claimedBy, entry.claimedUntil = owner, now.Add(lease)
+
+		claimed := entry.PendingEvent
+		claimed.DeliveredTo = copyStrings(claimed.DeliveredTo)
+		pending = append(pending, claimed)
+	}
+	return pending, nil
+}
+
+func (m *MemoryStorage) EventDeliveries(ctx context.Context, eventID string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	for _, entry := range m.outbox {
+		if entry.Event.ID == eventID {
+			return copyStrings(entry.DeliveredTo), nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *MemoryStorage) MarkEventDelivered(ctx context.Context, eventID, sink string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i := range m.outbox {
+		entry := &m.outbox[i]
+		if entry.Event.ID == eventID && !containsString(entry.DeliveredTo, sink) {
+			entry.DeliveredTo = append(entry.DeliveredTo, sink)
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) MarkEventPublished(ctx context.Context, eventID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	for i, entry := range m.outbox {
+		if entry.Event.ID == eventID {
+			m.outbox = append(m.outbox[:i], m.outbox[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) ClaimIdempotencyKey(ctx context.Context, key, requestHash string, lease time.Duration) (*models.IdempotentResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.lock()()
+
+	now := time.Now()
+	if existing, ok := m.idempotency[key]; ok && now.Before(existing.expiresAt) {
+		response := existing.IdempotentResponse
+		return &response, nil
+	}
+
+	m.idempotency[key] = memIdempotentResponse{
+		IdempotentResponse: models.IdempotentResponse{Key: key, RequestHash: requestHash},
+		expiresAt:          now.Add(lease),
+	}
+	return nil, nil
+}
+
+func (m *MemoryStorage) SaveIdempotentResponse(ctx context.Context, response models.IdempotentResponse, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if existing, ok := m.idempotency[response.Key]; ok {
+		response.RequestHash = existing.RequestHash
+		m.idempotency[response.Key] = memIdempotentResponse{IdempotentResponse: response, expiresAt: time.Now().Add(ttl)}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) ReleaseIdempotencyKey(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	defer m.lock()()
+
+	if existing, ok := m.idempotency[key]; ok && existing.StatusCode == 0 {
+		delete(m.idempotency, key)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) WithActor(actor string) Store {
+	return &MemoryStorage{memState: m.memState, actor: actor, inTx: m.inTx}
+}
+
+// InTx runs fn while holding the write lock, so no other call interleaves
+// with it. If fn fails, the writes it made are rolled back.
+func (m *MemoryStorage) InTx(ctx context.Context, fn func(tx Store) error) error {
+	if m.inTx {
+		return fn(m)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := m.memData.clone()
+	err := fn(&MemoryStorage{memState: m.memState, actor: m.actor, inTx: true})
+	if err != nil {
+		m.memData = saved
+	}
+	return err
+}
+
+// clone copies the data deep enough that no write through the store changes
+// the copy: slices and maps that are updated in place are copied too.
+func (d memData) clone() memData {
+	users := make(map[string]models.User, len(d.users))
+	for id, user := range d.users {
+		user.Logins = maps.Clone(user.Logins)
+		users[id] = user
+	}
+	prs := make(map[string]models.PullRequest, len(d.prs))
+	for id, pr := range d.prs {
+		pr.AssignedReviewers = copyStrings(pr.AssignedReviewers)
+		prs[id] = pr
+	}
+	reviewers := make(map[string][]memAssignment, len(d.reviewers))
+	for id, history := range d.reviewers {
+		reviewers[id] = slices.Clone(history)
+	}
+
+	d.teams = maps.Clone(d.teams)
+	d.users = users
+	d.userOrder = slices.Clone(d.userOrder)
+	d.identities = maps.Clone(d.identities)
+	d.prs = prs
+	d.prOrder = slices.Clone(d.prOrder)
+	d.reviewers = reviewers
+	d.subscriptions = slices.Clone(d.subscriptions)
+	d.deadLetters = slices.Clone(d.deadLetters)
+	d.availability = slices.Clone(d.availability)
+	d.exclusions = slices.Clone(d.exclusions)
+	d.assignments = slices.Clone(d.assignments)
+	d.outbox = slices.Clone(d.outbox)
+	d.audit = slices.Clone(d.audit)
+	d.idempotency = maps.Clone(d.idempotency)
+	return d
+}
+
+// record appends to the audit log; callers hold the write lock.
+func (m *MemoryStorage) record(event models.AuditEvent) {
+	event.ID = int64(len(m.audit) + 1)
+	event.Actor = m.actor
+	if event.Actor == "" {
+		event.Actor = models.DefaultActor
+	}
+	event.CreatedAt = time.Now().UTC()
+	m.audit = append(m.audit, event)
+}
+
+func (m *MemoryStorage) auditReviewerChange(pr models.PullRequest, before, after []string) {
+	m.record(prAudit(models.AuditPRReviewersUpdated, pr.PullRequestID, m.users[pr.AuthorID].TeamName,
+		reviewerDiff(before, after), map[string][]string{"reviewers": before}, map[string][]string{"reviewers": after}))
+}
+
+func (m *MemoryStorage) ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	defer m.rlock()()
+
+	limit := auditLimit(filter.Limit)
+	auditEvents := []models.AuditEvent{}
+	for _, event := range m.audit {
+		if len(auditEvents) == limit {
+			break
+		}
+		if event.ID <= filter.AfterID ||
+			(filter.PullRequestID != "" && event.PullRequestID != filter.PullRequestID) ||
+			(filter.UserID != "" && !containsString(event.UserIDs, filter.UserID)) ||
+			(filter.TeamName != "" && event.TeamName != filter.TeamName) ||
+			(filter.Action != "" && event.Action != filter.Action) ||
+			(!filter.From.IsZero() && event.CreatedAt.Before(filter.From)) ||
+			(!filter.To.IsZero() && !event.CreatedAt.Before(filter.To)) {
+			continue
+		}
+		event.UserIDs = copyStrings(event.UserIDs)
+		auditEvents = append(auditEvents, event)
+	}
+	return auditEvents, nil
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func prCreatedEvent(pr models.PullRequest) events.Event {
+	return events.New(events.TypePRCreated, map[string]interface{}{
+		"pr": pr,
+	})
+}
+
+func prMergedEvent(prID string) events.Event {
+	return events.New(events.TypePRMerged, map[string]interface{}{
+		"pull_request_id": prID,
+	})
+}
+
+func reviewerAssignedEvent(prID, userID, assignedBy string) events.Event {
+	return events.New(events.TypeReviewerAssigned, map[string]interface{}{
+		"pull_request_id": prID,
+		"reviewer_id":     userID,
+		"assigned_by":     assignedBy,
+	})
+}
+
+func reviewerReplacedEvent(prID, oldUserID, newUserID, assignedBy string) events.Event {
+	return events.New(events.TypeReviewerReplaced, map[string]interface{}{
+		"pull_request_id": prID,
+		"old_reviewer_id": oldUserID,
+		"new_reviewer_id": newUserID,
+		"assigned_by":     assignedBy,
+	})
+}
+
+func teamDeactivatedEvent(teamName string, result map[string]interface{}) events.Event {
+	return events.New(events.TypeTeamDeactivated, map[string]interface{}{
+		"team_name": teamName,
+		"result":    result,
+	})
+}
+
+// writeEvent stores an event in the outbox. It must run on the transaction
+// that makes the change the event describes.
+func writeEvent(ctx context.Context, q querier, event events.Event) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO outbox_events (event_id, event_type, payload, occurred_at)
+		VALUES ($1, $2, $3, $4)
+	`, event.ID, event.Type, []byte(event.Data), event.OccurredAt)
+	return err
+}
+
+// PendingEvents claims the events with SKIP LOCKED, so two relays claiming at
+// the same time get disjoint batches.
+func (s *Storage) PendingEvents(ctx context.Context, owner string, limit int, lease time.Duration) (_ []events.PendingEvent, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		WITH claimed AS (
+			UPDATE outbox_events
+			SET claimed_by = $1, claimed_until = CURRENT_TIMESTAMP + make_interval(secs => $3)
+			WHERE id IN (
+				SELECT id FROM outbox_events
+				WHERE published_at IS NULL
+					AND (claimed_by = $1 OR claimed_until IS NULL OR claimed_until <= CURRENT_TIMESTAMP)
+				ORDER BY id
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, event_id, event_type, payload, occurred_at
+		)
+		SELECT e.event_id, e.event_type, e.payload, e.occurred_at,
+			COALESCE(array_agg(d.sink) FILTER (WHERE d.sink IS NOT NULL), '{}')
+		FROM claimed e
+		LEFT JOIN outbox_deliveries d ON d.event_id = e.event_id
+		GROUP BY e.id, e.event_id, e.event_type, e.payload, e.occurred_at
+		ORDER BY e.id
+	`, owner, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []events.PendingEvent
+	for rows.Next() {
+		var entry events.PendingEvent
+		var payload []byte
+		err := rows.Scan(&entry.Event.ID, &entry.Event.Type, &payload, &entry.Event.OccurredAt,
+			(*pq.StringArray)(&entry.DeliveredTo))
+		if err != nil {
+			return nil, err
+		}
+		entry.Event.Data = payload
+		pending = append(pending, entry)
+	}
+
+	return pending, rows.Err()
+}
+
+func (s *Storage) EventDeliveries(ctx context.Context, eventID string) (_ []string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT sink FROM outbox_deliveries WHERE event_id = $1 ORDER BY sink
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sinks []string
+	for rows.Next() {
+		var sink string
+		if err := rows.Scan(&sink); err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	return sinks, rows.Err()
+}
+
+func (s *Storage) MarkEventDelivered(ctx context.Context, eventID, sink string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	_, err = s.q().ExecContext(ctx, `
+		INSERT INTO outbox_deliveries (event_id, sink) VALUES ($1, $2)
+		ON CONFLICT (event_id, sink) DO NOTHING
+	`, eventID, sink)
+	return err
+}
+
+func (s *Storage) MarkEventPublished(ctx context.Context, eventID string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	_, err = s.q().ExecContext(ctx, `
+		UPDATE outbox_events SET published_at = CURRENT_TIMESTAMP
+		WHERE event_id = $1 AND published_at IS NULL
+	`, eventID)
+	return err
+}
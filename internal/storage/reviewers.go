@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const activeReviewersExpr = `COALESCE((
+	SELECT array_agg(r.user_id ORDER BY r.position)
+	FROM pr_reviewers r
+	WHERE r.pull_request_id = pr.pull_request_id AND r.state = 'ASSIGNED'
+), '{}')`
+
+func loadActiveReviewers(ctx context.Context, q querier, prID string) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT user_id FROM pr_reviewers
+		WHERE pull_request_id = $1 AND state = 'ASSIGNED'
+		ORDER BY position
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviewers := []string{}
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, userID)
+	}
+
+	return reviewers, rows.Err()
+}
+
+func insertReviewerRow(ctx context.Context, q querier, prID, userID string, position int, assignedBy string) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO pr_reviewers (pull_request_id, user_id, position, assigned_by, state, source_team)
+		VALUES ($1, $2, $3, $4, 'ASSIGNED', (SELECT team_name FROM users WHERE user_id = $2))
+	`, prID, userID, position, assignedBy)
+	return err
+}
+
+func insertReviewer(ctx context.Context, q querier, prID, userID string, position int, assignedBy string) error {
+	if err := insertReviewerRow(ctx, q, prID, userID, position, assignedBy); err != nil {
+		return err
+	}
+	return writeEvent(ctx, q, reviewerAssignedEvent(prID, userID, assignedBy))
+}
+
+func nextReviewerPosition(ctx context.Context, q querier, prID string) (int, error) {
+	var position int
+	err := q.QueryRowContext(ctx, `
+		SELECT COALESCE(MAX(position) + 1, 0) FROM pr_reviewers WHERE pull_request_id = $1
+	`, prID).Scan(&position)
+	return position, err
+}
+
+func replaceReviewer(ctx context.Context, q querier, prID, oldUserID, newUserID, assignedBy string) error {
+	var position int
+	err := q.QueryRowContext(ctx, `
+		UPDATE pr_reviewers
+		SET state = 'REPLACED', replaced_by = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $1 AND user_id = $2 AND state = 'ASSIGNED'
+		RETURNING position
+	`, prID, oldUserID, newUserID).Scan(&position)
+	if err != nil {
+		return err
+	}
+
+	if err := insertReviewerRow(ctx, q, prID, newUserID, position, assignedBy); err != nil {
+		return err
+	}
+	return writeEvent(ctx, q, reviewerReplacedEvent(prID, oldUserID, newUserID, assignedBy))
+}
+
+func removeReviewers(ctx context.Context, q querier, prID string, userIDs []string) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE pr_reviewers
+		SET state = 'REMOVED', updated_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $1 AND state = 'ASSIGNED' AND user_id = ANY($2)
+	`, prID, pq.Array(userIDs))
+	return err
+}
+
+// setReviewers brings the active reviewer set of a PR to exactly reviewers:
+// missing users are appended, users no longer listed are marked REMOVED.
+func setReviewers(ctx context.Context, q querier, prID string, reviewers []string, assignedBy string) error {
+	current, err := loadActiveReviewers(ctx, q, prID)
+	if err != nil {
+		return err
+	}
+
+	var removed []string
+	for _, userID := range current {
+		if !containsString(reviewers, userID) {
+			removed = append(removed, userID)
+		}
+	}
+	if len(removed) > 0 {
+		if err := removeReviewers(ctx, q, prID, removed); err != nil {
+			return err
+		}
+	}
+
+	position, err := nextReviewerPosition(ctx, q, prID)
+	if err != nil {
+		return err
+	}
+	for _, userID := range reviewers {
+		if containsString(current, userID) {
+			continue
+		}
+		if err := insertReviewer(ctx, q, prID, userID, position, assignedBy); err != nil {
+			return err
+		}
+		position++
+	}
+
+	return nil
+}
+
+func (s *Storage) ReplacePRReviewer(ctx context.Context, prID, oldUserID, newUserID string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var authorID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT author_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, prID).Scan(&authorID)
+	if err != nil {
+		return err
+	}
+
+	if err := replaceReviewer(ctx, tx, prID, oldUserID, newUserID, models.AssignedByReassign); err != nil {
+		return err
+	}
+
+	if err := bumpPRVersion(ctx, tx, prID); err != nil {
+		return err
+	}
+
+	teamName, err := userTeam(ctx, tx, authorID)
+	if err != nil {
+		return err
+	}
+	audit := prAudit(models.AuditReviewerReplaced, prID, teamName, []string{oldUserID, newUserID},
+		map[string]string{"reviewer_id": oldUserID}, map[string]string{"reviewer_id": newUserID})
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) GetPRReviewerHistory(ctx context.Context, prID string) (_ []models.ReviewerAssignment, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT user_id, assigned_at, assigned_by, source_team, state, replaced_by, verdict
+		FROM pr_reviewers
+		WHERE pull_request_id = $1
+		ORDER BY assigned_at, id
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []models.ReviewerAssignment{}
+	for rows.Next() {
+		var assignment models.ReviewerAssignment
+		err := rows.Scan(&assignment.UserID, &assignment.AssignedAt, &assignment.AssignedBy, &assignment.SourceTeam,
+			&assignment.State, &assignment.ReplacedBy, &assignment.Verdict)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, assignment)
+	}
+
+	return history, rows.Err()
+}
+
+func loadReviews(ctx context.Context, q querier, prID string) ([]models.Review, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT user_id, verdict, COALESCE(verdict_comment, ''), reviewed_at
+		FROM pr_reviewers
+		WHERE pull_request_id = $1 AND state = 'ASSIGNED' AND verdict IS NOT NULL
+		ORDER BY position
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reviews []models.Review
+	for rows.Next() {
+		var review models.Review
+		if err := rows.Scan(&review.ReviewerID, &review.Verdict, &review.Comment, &review.ReviewedAt); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+
+	return reviews, rows.Err()
+}
+
+func (s *Storage) SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict, comment string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var before models.Review
+	var authorID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(r.verdict, ''), COALESCE(r.verdict_comment, ''), pr.author_id
+		FROM pr_reviewers r
+		JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+		WHERE r.pull_request_id = $1 AND r.user_id = $2 AND r.state = 'ASSIGNED'
+		FOR UPDATE OF r
+	`, prID, reviewerID).Scan(&before.Verdict, &before.Comment, &authorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE pr_reviewers
+		SET verdict = $3, verdict_comment = NULLIF($4, ''), reviewed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE pull_request_id = $1 AND user_id = $2 AND state = 'ASSIGNED'
+	`, prID, reviewerID, verdict, comment)
+	if err != nil {
+		return err
+	}
+
+	if err := bumpPRVersion(ctx, tx, prID); err != nil {
+		return err
+	}
+
+	var previous interface{}
+	if before.Verdict != "" {
+		previous = map[string]string{"verdict": before.Verdict, "comment": before.Comment}
+	}
+	teamName, err := userTeam(ctx, tx, authorID)
+	if err != nil {
+		return err
+	}
+	audit := prAudit(models.AuditReviewSubmitted, prID, teamName, []string{reviewerID},
+		previous, map[string]string{"verdict": verdict, "comment": comment})
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,17 +1,25 @@
 package storage
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
 	"fmt"
+	"pr-reviewer-service/internal/assignment"
 	"pr-reviewer-service/internal/config"
+	"pr-reviewer-service/internal/migrate"
 	"pr-reviewer-service/internal/models"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Storage struct {
-	db *sql.DB
+	db      *sql.DB
+	tx      *sql.Tx
+	actor   string
+	timeout time.Duration
 }
 
 func (s *Storage) Close() error {
@@ -22,36 +30,162 @@ func (s *Storage) Close() error {
 }
 
 func NewStorage(db *sql.DB) *Storage {
-	return &Storage{db: db}
+	return &Storage{db: db, timeout: config.QueryTimeout}
 }
 
-func (s *Storage) CreateTeam(team models.Team) error {
-	_, err := s.db.Exec("INSERT INTO teams (team_name) VALUES ($1) ON CONFLICT (team_name) DO NOTHING", team.TeamName)
+// withTimeout bounds a storage call by the configured query timeout. The
+// returned func releases the deadline and, when the caller's context or the
+// deadline aborted the call, replaces the driver error with the context's, so
+// callers can tell cancellation from failure.
+func (s *Storage) withTimeout(ctx context.Context, err *error) (context.Context, func()) {
+	cancel := context.CancelFunc(func() {})
+	if s.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+	}
+	return ctx, func() {
+		if *err != nil && ctx.Err() != nil {
+			*err = ctx.Err()
+		}
+		cancel()
+	}
+}
+
+func (s *Storage) CreateTeam(ctx context.Context, team models.Team) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	settings := assignment.DefaultSettings(team.TeamName)
+	if team.Settings != nil {
+		settings = *team.Settings
+	}
+
+	rules, err := ownershipRules(settings)
 	if err != nil {
 		return err
 	}
 
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO teams (team_name, min_reviewers, max_reviewers, assignment_strategy, allow_cross_team_fallback, required_approvals,
+			fallback_teams, max_open_reviews, ownership_rules, min_reviewer_role, max_consecutive_reviews)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (team_name) DO NOTHING
+	`, team.TeamName, settings.MinReviewers, settings.MaxReviewers, settings.AssignmentStrategy, settings.AllowCrossTeamFallback,
+		settings.RequiredApprovals, pq.Array(fallbackTeams(settings)), settings.MaxOpenReviews, rules, settings.MinReviewerRole,
+		settings.MaxConsecutiveReviews)
+	if err != nil {
+		return err
+	}
+
+	if created, _ := res.RowsAffected(); created > 0 {
+		audit := newAudit(models.AuditTeamCreated, models.EntityTeam, team.TeamName, nil, settings)
+		audit.TeamName = team.TeamName
+		if err := s.writeAudit(ctx, tx, audit); err != nil {
+			return err
+		}
+	}
+
 	for _, member := range team.Members {
-		_, err := s.db.Exec(`
-			INSERT INTO users (user_id, username, team_name, is_active) 
-			VALUES ($1, $2, $3, $4)
+		before, err := scanUser(tx.QueryRowContext(ctx, `
+			SELECT `+userColumns+` FROM users WHERE user_id = $1 FOR UPDATE
+		`, member.UserID))
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO users (user_id, username, team_name, is_active, max_open_reviews, skills, role) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
 			ON CONFLICT (user_id) DO UPDATE SET 
-				username = $2, team_name = $3, is_active = $4
-		`, member.UserID, member.Username, team.TeamName, member.IsActive) // ← Убедись что team.TeamName
+				username = $2, team_name = $3, is_active = $4, max_open_reviews = $5, skills = $6, role = $7
+		`, member.UserID, member.Username, team.TeamName, member.IsActive, member.MaxOpenReviews, skillSet(member.Skills), member.Role) // ← Убедись что team.TeamName
 		if err != nil {
 			return err
 		}
+
+		for provider, login := range member.Logins {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO user_identities (provider, login, user_id)
+				VALUES ($1, $2, $3)
+				ON CONFLICT (provider, login) DO UPDATE SET user_id = $3
+			`, provider, login, member.UserID)
+			if err != nil {
+				return err
+			}
+		}
+
+		if before != nil && before.TeamName != team.TeamName {
+			if err := bumpTeamVersion(ctx, tx, before.TeamName); err != nil {
+				return err
+			}
+		}
+
+		after := member
+		after.TeamName = team.TeamName
+		audit := newAudit(models.AuditUserSaved, models.EntityUser, member.UserID, before, after)
+		audit.TeamName = team.TeamName
+		audit.UserIDs = []string{member.UserID}
+		if err := s.writeAudit(ctx, tx, audit); err != nil {
+			return err
+		}
 	}
 
+	return tx.Commit()
+}
+
+func (s *Storage) GetUserByID(ctx context.Context, userID string) (_ *models.User, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return scanUser(s.q().QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users WHERE user_id = $1
+	`, userID))
+}
+
+const userColumns = "user_id, username, team_name, is_active, max_open_reviews, skills, role"
+
+// userFields lists the scan targets for userColumns.
+func userFields(user *models.User) []interface{} {
+	return []interface{}{&user.UserID, &user.Username, &user.TeamName, &user.IsActive, &user.MaxOpenReviews,
+		(*skillSet)(&user.Skills), &user.Role}
+}
+
+// skillSet reads and writes a user's skills as a JSONB object; a user with no
+// skills scans as nil.
+type skillSet map[string]int
+
+func (s *skillSet) Scan(src interface{}) error {
+	data, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("skills: unexpected type %T", src)
+	}
+	var skills map[string]int
+	if err := json.Unmarshal(data, &skills); err != nil {
+		return err
+	}
+	if len(skills) == 0 {
+		skills = nil
+	}
+	*s = skills
 	return nil
 }
 
-func (s *Storage) GetUserByID(userID string) (*models.User, error) {
+func (s skillSet) Value() (driver.Value, error) {
+	if s == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(map[string]int(s))
+}
+
+func scanUser(row *sql.Row) (*models.User, error) {
 	var user models.User
-	err := s.db.QueryRow(`
-		SELECT user_id, username, team_name, is_active 
-		FROM users WHERE user_id = $1
-	`, userID).Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+	err := row.Scan(userFields(&user)...)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -63,9 +197,35 @@ func (s *Storage) GetUserByID(userID string) (*models.User, error) {
 	return &user, nil
 }
 
-func (s *Storage) GetActiveTeamMembers(teamName, excludeUserID string) ([]models.User, error) {
-	rows, err := s.db.Query(`
-		SELECT user_id, username, team_name, is_active 
+func (s *Storage) GetUserByLogin(ctx context.Context, provider, login string) (_ *models.User, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	var user models.User
+	err = s.q().QueryRowContext(ctx, `
+		SELECT u.user_id, u.username, u.team_name, u.is_active, u.max_open_reviews, u.skills, u.role
+		FROM user_identities i
+		JOIN users u ON u.user_id = i.user_id
+		WHERE i.provider = $1 AND i.login = $2
+	`, provider, login).Scan(userFields(&user)...)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user.Logins = map[string]string{provider: login}
+	return &user, nil
+}
+
+func (s *Storage) GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string) (_ []models.User, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+userColumns+`
 		FROM users 
 		WHERE team_name = $1 AND is_active = true AND user_id != $2
 	`, teamName, excludeUserID)
@@ -77,7 +237,7 @@ func (s *Storage) GetActiveTeamMembers(teamName, excludeUserID string) ([]models
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		err := rows.Scan(userFields(&user)...)
 		if err != nil {
 			return nil, err
 		}
@@ -87,26 +247,75 @@ func (s *Storage) GetActiveTeamMembers(teamName, excludeUserID string) ([]models
 	return users, nil
 }
 
-func (s *Storage) CreatePR(pr models.PullRequest) error {
-	reviewersJSON, _ := json.Marshal(pr.AssignedReviewers)
+func (s *Storage) CreatePR(ctx context.Context, pr models.PullRequest) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
 
-	_, err := s.db.Exec(`
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
 		INSERT INTO pull_requests 
-		(pull_request_id, pull_request_name, author_id, status, assigned_reviewers) 
-		VALUES ($1, $2, $3, $4, $5)
-	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, reviewersJSON)
+		(pull_request_id, pull_request_name, author_id, status, changed_files, required_tags) 
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status, pq.Array(nonNil(pr.ChangedFiles)), pq.Array(nonNil(pr.RequiredTags)))
+	if isUniqueViolation(err, "pull_requests_pkey") {
+		return ErrPRExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := writeEvent(ctx, tx, prCreatedEvent(pr)); err != nil {
+		return err
+	}
 
-	return err
+	for i, reviewer := range pr.AssignedReviewers {
+		if err := insertReviewer(ctx, tx, pr.PullRequestID, reviewer, i, models.AssignedByCreate); err != nil {
+			return err
+		}
+	}
+
+	teamName, err := userTeam(ctx, tx, pr.AuthorID)
+	if err != nil {
+		return err
+	}
+	userIDs := append([]string{pr.AuthorID}, pr.AssignedReviewers...)
+	if err := s.writeAudit(ctx, tx, prAudit(models.AuditPRCreated, pr.PullRequestID, teamName, userIDs, nil, pr)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) GetPRByID(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return s.getPR(ctx, prID, "")
 }
 
-func (s *Storage) GetPRByID(prID string) (*models.PullRequest, error) {
+// GetPRForUpdate reads the PR and locks its row until the InTx transaction
+// ends, so concurrent changes to the same PR run one after another.
+func (s *Storage) GetPRForUpdate(ctx context.Context, prID string) (_ *models.PullRequest, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return s.getPR(ctx, prID, "FOR UPDATE OF pr")
+}
+
+func (s *Storage) getPR(ctx context.Context, prID, lock string) (*models.PullRequest, error) {
 	var pr models.PullRequest
-	var reviewersJSON string
 
-	err := s.db.QueryRow(`
-		SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at
-		FROM pull_requests WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &reviewersJSON, &pr.CreatedAt, &pr.MergedAt)
+	err := s.q().QueryRowContext(ctx, `
+		SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, `+activeReviewersExpr+`, pr.created_at, pr.merged_at, pr.closed_at, pr.version,
+			pr.changed_files, pr.required_tags
+		FROM pull_requests pr WHERE pr.pull_request_id = $1
+	`+lock, prID).Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, (*pq.StringArray)(&pr.AssignedReviewers), &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt,
+		&pr.Version, (*pq.StringArray)(&pr.ChangedFiles), (*pq.StringArray)(&pr.RequiredTags))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -115,19 +324,55 @@ func (s *Storage) GetPRByID(prID string) (*models.PullRequest, error) {
 		return nil, err
 	}
 
-	json.Unmarshal([]byte(reviewersJSON), &pr.AssignedReviewers)
+	pr.Reviews, err = loadReviews(ctx, s.q(), prID)
+	if err != nil {
+		return nil, err
+	}
 
 	return &pr, nil
 }
 
-func (s *Storage) UpdateUserActive(userID string, isActive bool) error {
-	_, err := s.db.Exec(`
+func (s *Storage) UpdateUserActive(ctx context.Context, userID string, isActive bool) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE user_id = $1 FOR UPDATE
+	`, userID))
+	if err != nil || user == nil || user.IsActive == isActive {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
 		UPDATE users SET is_active = $1 WHERE user_id = $2
 	`, isActive, userID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if err := bumpTeamVersion(ctx, tx, user.TeamName); err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditUserActiveChanged, models.EntityUser, userID,
+		map[string]bool{"is_active": user.IsActive}, map[string]bool{"is_active": isActive})
+	audit.TeamName = user.TeamName
+	audit.UserIDs = []string{userID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func InitDB() (*sql.DB, error) {
+// OpenDB connects to Postgres without touching the schema.
+func OpenDB() (*sql.DB, error) {
 	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
 		config.Host, config.Port, config.User, config.Password, config.DBName)
 
@@ -144,18 +389,53 @@ func InitDB() (*sql.DB, error) {
 	return db, nil
 }
 
-func (s *Storage) GetTeam(teamName string) (*models.Team, error) {
-	var exists bool
-	err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)", teamName).Scan(&exists)
+// InitDB connects to Postgres and brings the schema up to date. With
+// MIGRATE_ON_START disabled it refuses to start against a schema that still
+// has pending migrations.
+func InitDB() (*sql.DB, error) {
+	db, err := OpenDB()
 	if err != nil {
 		return nil, err
 	}
-	if !exists {
-		return nil, nil
+
+	runner, err := migrate.New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if config.MigrateOnStart {
+		if _, err := runner.Up(); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	pending, err := runner.Pending()
+	if err != nil {
+		db.Close()
+		return nil, err
 	}
+	if len(pending) > 0 {
+		db.Close()
+		return nil, fmt.Errorf("database schema is behind: %d pending migrations, first is %03d_%s",
+			len(pending), pending[0].Version, pending[0].Name)
+	}
+
+	return db, nil
+}
 
-	rows, err := s.db.Query(`
-		SELECT user_id, username, team_name, is_active 
+func (s *Storage) GetTeam(ctx context.Context, teamName string) (_ *models.Team, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	settings, err := s.GetTeamSettings(ctx, teamName)
+	if err != nil || settings == nil {
+		return nil, err
+	}
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+userColumns+`
 		FROM users WHERE team_name = $1
 	`, teamName)
 	if err != nil {
@@ -166,45 +446,397 @@ func (s *Storage) GetTeam(teamName string) (*models.Team, error) {
 	var members []models.User
 	for rows.Next() {
 		var user models.User
-		err := rows.Scan(&user.UserID, &user.Username, &user.TeamName, &user.IsActive)
+		err := rows.Scan(userFields(&user)...)
 		if err != nil {
 			return nil, err
 		}
 		members = append(members, user)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := s.loadLogins(ctx, members); err != nil {
+		return nil, err
+	}
 
 	return &models.Team{
 		TeamName: teamName,
+		Settings: settings,
 		Members:  members,
 	}, nil
 }
 
-func (s *Storage) UpdatePRStatus(prID string, status string) error {
-	if status == "MERGED" {
-		_, err := s.db.Exec(`
-            UPDATE pull_requests 
-            SET status = $1, merged_at = CURRENT_TIMESTAMP 
-            WHERE pull_request_id = $2 AND status != 'MERGED'
-        `, status, prID)
+func (s *Storage) loadLogins(ctx context.Context, users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	index := make(map[string]*models.User, len(users))
+	userIDs := make([]string, len(users))
+	for i := range users {
+		index[users[i].UserID] = &users[i]
+		userIDs[i] = users[i].UserID
+	}
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT user_id, provider, login FROM user_identities WHERE user_id = ANY($1)
+	`, pq.Array(userIDs))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, provider, login string
+		if err := rows.Scan(&userID, &provider, &login); err != nil {
+			return err
+		}
+		user := index[userID]
+		if user.Logins == nil {
+			user.Logins = make(map[string]string)
+		}
+		user.Logins[provider] = login
+	}
+
+	return rows.Err()
+}
+
+const teamSettingsColumns = "team_name, min_reviewers, max_reviewers, assignment_strategy, allow_cross_team_fallback, required_approvals, fallback_teams, " +
+	"max_open_reviews, ownership_rules, min_reviewer_role, max_consecutive_reviews, version"
+
+func scanTeamSettings(row *sql.Row) (*models.TeamSettings, error) {
+	var settings models.TeamSettings
+	var rules []byte
+	err := row.Scan(&settings.TeamName, &settings.MinReviewers, &settings.MaxReviewers,
+		&settings.AssignmentStrategy, &settings.AllowCrossTeamFallback, &settings.RequiredApprovals,
+		(*pq.StringArray)(&settings.FallbackTeams), &settings.MaxOpenReviews, &rules, &settings.MinReviewerRole,
+		&settings.MaxConsecutiveReviews, &settings.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(rules, &settings.OwnershipRules); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ownershipRules encodes the rules for the JSONB column; a missing list is
+// stored as an empty one.
+func ownershipRules(settings models.TeamSettings) ([]byte, error) {
+	if settings.OwnershipRules == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(settings.OwnershipRules)
+}
+
+// nonNil keeps a missing list from being written as NULL.
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// fallbackTeams keeps a missing list from being written as NULL.
+func fallbackTeams(settings models.TeamSettings) []string {
+	if settings.FallbackTeams == nil {
+		return []string{}
+	}
+	return settings.FallbackTeams
+}
+
+func (s *Storage) GetTeamSettings(ctx context.Context, teamName string) (_ *models.TeamSettings, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return scanTeamSettings(s.q().QueryRowContext(ctx, "SELECT "+teamSettingsColumns+" FROM teams WHERE team_name = $1", teamName))
+}
+
+func (s *Storage) GetTeamSettingsForUpdate(ctx context.Context, teamName string) (_ *models.TeamSettings, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	return scanTeamSettings(s.q().QueryRowContext(ctx, "SELECT "+teamSettingsColumns+" FROM teams WHERE team_name = $1 FOR UPDATE", teamName))
+}
+
+func (s *Storage) UpdateTeamSettings(ctx context.Context, settings models.TeamSettings) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	before, err := scanTeamSettings(tx.QueryRowContext(ctx, "SELECT "+teamSettingsColumns+" FROM teams WHERE team_name = $1 FOR UPDATE",
+		settings.TeamName))
+	if err != nil || before == nil {
+		return err
+	}
+
+	rules, err := ownershipRules(settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE teams
+		SET min_reviewers = $2, max_reviewers = $3, assignment_strategy = $4, allow_cross_team_fallback = $5,
+			required_approvals = $6, fallback_teams = $7, max_open_reviews = $8, ownership_rules = $9, min_reviewer_role = $10,
+			max_consecutive_reviews = $11, version = version + 1
+		WHERE team_name = $1
+	`, settings.TeamName, settings.MinReviewers, settings.MaxReviewers, settings.AssignmentStrategy, settings.AllowCrossTeamFallback,
+		settings.RequiredApprovals, pq.Array(fallbackTeams(settings)), settings.MaxOpenReviews, rules, settings.MinReviewerRole,
+		settings.MaxConsecutiveReviews)
+	if err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditTeamSettingsUpdated, models.EntityTeam, settings.TeamName, before, settings)
+	audit.TeamName = settings.TeamName
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) GetActiveUsersOutsideTeam(ctx context.Context, teamName, excludeUserID string) (_ []models.User, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users 
+		WHERE team_name != $1 AND is_active = true AND user_id != $2
+	`, teamName, excludeUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		err := rows.Scan(userFields(&user)...)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+func (s *Storage) GetOpenReviewCounts(ctx context.Context, userIDs []string) (_ map[string]int, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT u.user_id, COUNT(pr.pull_request_id)
+		FROM users u
+		LEFT JOIN pr_reviewers r ON r.user_id = u.user_id AND r.state = 'ASSIGNED'
+		LEFT JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id AND pr.status IN ('OPEN', 'REOPENED')
+		WHERE u.user_id = ANY($1)
+		GROUP BY u.user_id
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int, len(userIDs))
+	for rows.Next() {
+		var userID string
+		var count int
+		if err := rows.Scan(&userID, &count); err != nil {
+			return nil, err
+		}
+		counts[userID] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// effectiveLimitExpr is a user's own limit, else the team default; a team
+// default of 0 means no limit.
+const effectiveLimitExpr = "COALESCE(u.max_open_reviews, NULLIF(t.max_open_reviews, 0))"
+
+func (s *Storage) GetReviewLimits(ctx context.Context, userIDs []string) (_ map[string]int, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT u.user_id, `+effectiveLimitExpr+`
+		FROM users u
+		LEFT JOIN teams t ON t.team_name = u.team_name
+		WHERE u.user_id = ANY($1) AND `+effectiveLimitExpr+` IS NOT NULL
+	`, pq.Array(userIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	limits := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var limit int
+		if err := rows.Scan(&userID, &limit); err != nil {
+			return nil, err
+		}
+		limits[userID] = limit
+	}
+
+	return limits, rows.Err()
+}
+
+func (s *Storage) SetUserMaxOpenReviews(ctx context.Context, userID string, limit *int) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err := s.db.Exec(`
-        UPDATE pull_requests SET status = $1 WHERE pull_request_id = $2
+	user, err := scanUser(tx.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE user_id = $1 FOR UPDATE
+	`, userID))
+	if err != nil || user == nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET max_open_reviews = $1 WHERE user_id = $2`, limit, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bumpTeamVersion(ctx, tx, user.TeamName); err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditUserReviewLimitChanged, models.EntityUser, userID,
+		map[string]*int{"max_open_reviews": user.MaxOpenReviews}, map[string]*int{"max_open_reviews": limit})
+	audit.TeamName = user.TeamName
+	audit.UserIDs = []string{userID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) SetUserRole(ctx context.Context, userID, role string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `
+		SELECT `+userColumns+` FROM users WHERE user_id = $1 FOR UPDATE
+	`, userID))
+	if err != nil || user == nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users SET role = $1 WHERE user_id = $2`, role, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := bumpTeamVersion(ctx, tx, user.TeamName); err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditUserRoleChanged, models.EntityUser, userID,
+		map[string]string{"role": user.Role}, map[string]string{"role": role})
+	audit.TeamName = user.TeamName
+	audit.UserIDs = []string{userID}
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) UpdatePRStatus(ctx context.Context, prID string, status string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current, authorID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status, author_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, prID).Scan(&current, &authorID)
+	if err == sql.ErrNoRows || current == status {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !models.CanTransition(current, status) {
+		return ErrInvalidTransition
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE pull_requests 
+        SET status = $1,
+            merged_at = CASE WHEN $1 = 'MERGED' THEN CURRENT_TIMESTAMP ELSE merged_at END,
+            closed_at = CASE WHEN $1 = 'CLOSED' THEN CURRENT_TIMESTAMP WHEN $1 = 'REOPENED' THEN NULL ELSE closed_at END,
+            version = version + 1
+        WHERE pull_request_id = $2
     `, status, prID)
-	return err
+	if err != nil {
+		return err
+	}
+
+	if status == models.StatusMerged {
+		if err := writeEvent(ctx, tx, prMergedEvent(prID)); err != nil {
+			return err
+		}
+	}
+
+	teamName, err := userTeam(ctx, tx, authorID)
+	if err != nil {
+		return err
+	}
+	audit := prAudit(models.AuditPRStatusChanged, prID, teamName, []string{authorID},
+		map[string]string{"status": current}, map[string]string{"status": status})
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Storage) GetPRsByReviewer(userID string) ([]models.PullRequest, error) {
-	rows, err := s.db.Query(`
-        SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at
-        FROM pull_requests 
-        WHERE assigned_reviewers::jsonb ? $1
-        ORDER BY created_at DESC
+func (s *Storage) GetPRsByReviewer(ctx context.Context, userID string) (_ []models.PullRequest, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+        SELECT pr.pull_request_id, pr.pull_request_name, pr.author_id, pr.status, `+activeReviewersExpr+`, pr.created_at, pr.merged_at
+        FROM pr_reviewers r
+        JOIN pull_requests pr ON pr.pull_request_id = r.pull_request_id
+        WHERE r.user_id = $1 AND r.state = 'ASSIGNED'
+        ORDER BY pr.created_at DESC
     `, userID)
 
 	if err != nil {
-		fmt.Printf("SQL error in GetPRsByReviewer: %v\n", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -212,47 +844,77 @@ func (s *Storage) GetPRsByReviewer(userID string) ([]models.PullRequest, error)
 	var prs []models.PullRequest
 	for rows.Next() {
 		var pr models.PullRequest
-		var reviewersJSON string
 
-		err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, &reviewersJSON, &pr.CreatedAt, &pr.MergedAt)
+		err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status, (*pq.StringArray)(&pr.AssignedReviewers), &pr.CreatedAt, &pr.MergedAt)
 		if err != nil {
-			fmt.Printf("Scan error in GetPRsByReviewer: %v\n", err)
 			return nil, err
 		}
-		if reviewersJSON != "" {
-			err = json.Unmarshal([]byte(reviewersJSON), &pr.AssignedReviewers)
-			if err != nil {
-				fmt.Printf("JSON unmarshal error in GetPRsByReviewer: %v\n", err)
-			}
-		}
 
 		prs = append(prs, pr)
 	}
 
-	return prs, nil
+	return prs, rows.Err()
 }
 
-func (s *Storage) GetUserTeam(userID string) (string, error) {
+func (s *Storage) GetUserTeam(ctx context.Context, userID string) (_ string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
 	var teamName string
-	err := s.db.QueryRow("SELECT team_name FROM users WHERE user_id = $1", userID).Scan(&teamName)
+	err = s.q().QueryRowContext(ctx, "SELECT team_name FROM users WHERE user_id = $1", userID).Scan(&teamName)
 	return teamName, err
 }
 
-func (s *Storage) UpdatePRReviewers(prID string, reviewers []string) error {
-	reviewersJSON, _ := json.Marshal(reviewers)
-	_, err := s.db.Exec(`
-        UPDATE pull_requests SET assigned_reviewers = $1 WHERE pull_request_id = $2
-    `, reviewersJSON, prID)
-	return err
+func (s *Storage) UpdatePRReviewers(ctx context.Context, prID string, reviewers []string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var authorID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT author_id FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE
+	`, prID).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	before, err := loadActiveReviewers(ctx, tx, prID)
+	if err != nil {
+		return err
+	}
+
+	if err := setReviewers(ctx, tx, prID, reviewers, models.AssignedByUpdate); err != nil {
+		return err
+	}
+
+	if err := bumpPRVersion(ctx, tx, prID); err != nil {
+		return err
+	}
+
+	if err := s.auditReviewerChange(ctx, tx, prID, authorID, before, reviewers); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (s *Storage) GetReviewStats() (map[string]interface{}, error) {
+func (s *Storage) GetReviewStats(ctx context.Context) (_ map[string]interface{}, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
 	stats := make(map[string]interface{})
-	rows, err := s.db.Query(`
-		SELECT u.user_id, u.username, 
-			COUNT(CASE WHEN pr.assigned_reviewers IS NOT NULL AND pr.assigned_reviewers::jsonb ? u.user_id THEN 1 END) as assignment_count
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT u.user_id, u.username, COUNT(r.id) as assignment_count
 		FROM users u
-		LEFT JOIN pull_requests pr ON pr.assigned_reviewers IS NOT NULL AND pr.assigned_reviewers::jsonb ? u.user_id
+		LEFT JOIN pr_reviewers r ON r.user_id = u.user_id AND r.state = 'ASSIGNED'
 		GROUP BY u.user_id, u.username
 		ORDER BY assignment_count DESC
 	`)
@@ -261,15 +923,9 @@ func (s *Storage) GetReviewStats() (map[string]interface{}, error) {
 	}
 	defer rows.Close()
 
-	type UserStat struct {
-		UserID   string `json:"user_id"`
-		Username string `json:"username"`
-		Count    int    `json:"assignment_count"`
-	}
-
-	var userStats []UserStat
+	var userStats []userStat
 	for rows.Next() {
-		var stat UserStat
+		var stat userStat
 		err := rows.Scan(&stat.UserID, &stat.Username, &stat.Count)
 		if err != nil {
 			return nil, err
@@ -277,54 +933,76 @@ func (s *Storage) GetReviewStats() (map[string]interface{}, error) {
 		userStats = append(userStats, stat)
 	}
 
-	var totalPRs, openPRs, mergedPRs int
-	s.db.QueryRow("SELECT COUNT(*) FROM pull_requests").Scan(&totalPRs)
-	s.db.QueryRow("SELECT COUNT(*) FROM pull_requests WHERE status = 'OPEN'").Scan(&openPRs)
-	s.db.QueryRow("SELECT COUNT(*) FROM pull_requests WHERE status = 'MERGED'").Scan(&mergedPRs)
+	var totalPRs, openPRs, mergedPRs, draftPRs, closedPRs int
+	s.q().QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests").Scan(&totalPRs)
+	s.q().QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests WHERE status IN ('OPEN', 'REOPENED')").Scan(&openPRs)
+	s.q().QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests WHERE status = 'MERGED'").Scan(&mergedPRs)
+	s.q().QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests WHERE status = 'DRAFT'").Scan(&draftPRs)
+	s.q().QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests WHERE status = 'CLOSED'").Scan(&closedPRs)
 
 	stats["user_assignments"] = userStats
 	stats["total_prs"] = totalPRs
 	stats["open_prs"] = openPRs
 	stats["merged_prs"] = mergedPRs
+	stats["draft_prs"] = draftPRs
+	stats["closed_prs"] = closedPRs
 	stats["total_users"] = len(userStats)
 
 	return stats, nil
 }
 
-func (s *Storage) BulkDeactivateTeamUsers(teamName string) (map[string]interface{}, error) {
-	result := make(map[string]interface{})
+func (s *Storage) DeactivateTeamUsers(ctx context.Context, teamName string) (_ []string, _ []string, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
 
-	tx, err := s.db.Begin()
+	tx, err := s.begin(ctx)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer tx.Rollback()
 
-	res, err := tx.Exec(`
+	deactivatedRows, err := tx.QueryContext(ctx, `
 		UPDATE users SET is_active = false 
 		WHERE team_name = $1
+		RETURNING user_id
 	`, teamName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	deactivatedCount, _ := res.RowsAffected()
-	result["deactivated_users"] = deactivatedCount
+	var deactivated []string
+	for deactivatedRows.Next() {
+		var userID string
+		if err := deactivatedRows.Scan(&userID); err != nil {
+			deactivatedRows.Close()
+			return nil, nil, err
+		}
+		deactivated = append(deactivated, userID)
+	}
+	deactivatedRows.Close()
+	if err := deactivatedRows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(deactivated) > 0 {
+		if err := bumpTeamVersion(ctx, tx, teamName); err != nil {
+			return nil, nil, err
+		}
+	}
 
 	var affectedPRs []string
-	rows, err := tx.Query(`
+	rows, err := tx.QueryContext(ctx, `
 		SELECT DISTINCT pr.pull_request_id 
 		FROM pull_requests pr
-		JOIN users u ON u.user_id = ANY(
-		SELECT value->>0 
-		FROM jsonb_array_elements(pr.assigned_reviewers)
-		)
-		WHERE pr.status = 'OPEN' 
+		JOIN pr_reviewers r ON r.pull_request_id = pr.pull_request_id AND r.state = 'ASSIGNED'
+		JOIN users u ON u.user_id = r.user_id
+		WHERE pr.status IN ('OPEN', 'REOPENED') 
 		AND u.team_name = $1 
 		AND u.is_active = false
+		ORDER BY pr.pull_request_id
 	`, teamName)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -332,109 +1010,98 @@ func (s *Storage) BulkDeactivateTeamUsers(teamName string) (map[string]interface
 		var prID string
 		err := rows.Scan(&prID)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		affectedPRs = append(affectedPRs, prID)
 	}
-
-	result["affected_prs"] = affectedPRs
-	result["reassigned_prs_count"] = 0
-
-	for _, prID := range affectedPRs {
-		reassigned, err := s.safeReassignPRReviewers(tx, prID, teamName)
-		if err != nil {
-			return nil, err
-		}
-		if reassigned {
-			result["reassigned_prs_count"] = result["reassigned_prs_count"].(int) + 1
-		}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
 	}
-	err = tx.Commit()
-	if err != nil {
-		return nil, err
-	}
-	return result, nil
-}
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+	return deactivated, affectedPRs, tx.Commit()
 }
 
-func (s *Storage) safeReassignPRReviewers(tx *sql.Tx, prID string, teamName string) (bool, error) {
-	var pr models.PullRequest
-	var reviewersJSON string
-
-	err := tx.QueryRow(`
-		SELECT pull_request_id, author_id, assigned_reviewers, status
-		FROM pull_requests WHERE pull_request_id = $1
-	`, prID).Scan(&pr.PullRequestID, &pr.AuthorID, &reviewersJSON, &pr.Status)
+func (s *Storage) ReplaceDeactivatedReviewers(ctx context.Context, prID string, removed, added []string) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
 
+	tx, err := s.begin(ctx)
 	if err != nil {
-		return false, err
+		return err
 	}
-	json.Unmarshal([]byte(reviewersJSON), &pr.AssignedReviewers)
+	defer tx.Rollback()
 
-	var deactivatedReviewers []string
-	var activeReviewers []string
+	var authorID string
+	err = tx.QueryRowContext(ctx, `
+		SELECT author_id FROM pull_requests WHERE pull_request_id = $1
+	`, prID).Scan(&authorID)
+	if err != nil {
+		return err
+	}
 
-	for _, reviewer := range pr.AssignedReviewers {
-		var isActive bool
-		err := tx.QueryRow(`
-			SELECT is_active FROM users WHERE user_id = $1
-		`, reviewer).Scan(&isActive)
+	before, err := loadActiveReviewers(ctx, tx, prID)
+	if err != nil {
+		return err
+	}
 
-		if err == nil && isActive {
-			activeReviewers = append(activeReviewers, reviewer)
+	for i, reviewer := range removed {
+		if i < len(added) {
+			err = replaceReviewer(ctx, tx, prID, reviewer, added[i], models.AssignedByBulkDeactivate)
 		} else {
-			deactivatedReviewers = append(deactivatedReviewers, reviewer)
+			err = removeReviewers(ctx, tx, prID, []string{reviewer})
+		}
+		if err != nil {
+			return err
 		}
 	}
 
-	if len(deactivatedReviewers) > 0 {
-		rows, err := tx.Query(`
-			SELECT user_id FROM users 
-			WHERE team_name = $1 
-			AND is_active = true 
-			AND user_id != $2
-			AND user_id NOT IN (SELECT jsonb_array_elements_text($3::jsonb))
-		`, teamName, pr.AuthorID, reviewersJSON)
-
+	if len(added) > len(removed) {
+		position, err := nextReviewerPosition(ctx, tx, prID)
 		if err != nil {
-			return false, err
+			return err
 		}
-		defer rows.Close()
-
-		var availableUsers []string
-		for rows.Next() {
-			var userID string
-			err := rows.Scan(&userID)
-			if err != nil {
-				return false, err
+		for i, reviewer := range added[len(removed):] {
+			if err := insertReviewer(ctx, tx, prID, reviewer, position+i, models.AssignedByBulkDeactivate); err != nil {
+				return err
 			}
-			availableUsers = append(availableUsers, userID)
-		}
-		newReviewers := activeReviewers
-		needed := 2 - len(activeReviewers)
-		if needed > 0 && len(availableUsers) > 0 {
-			count := min(needed, len(availableUsers))
-			newReviewers = append(newReviewers, availableUsers[:count]...)
 		}
+	}
 
-		newReviewersJSON, _ := json.Marshal(newReviewers)
-		_, err = tx.Exec(`
-			UPDATE pull_requests SET assigned_reviewers = $1 
-			WHERE pull_request_id = $2
-		`, newReviewersJSON, prID)
+	if err := bumpPRVersion(ctx, tx, prID); err != nil {
+		return err
+	}
 
-		if err != nil {
-			return false, err
-		}
+	after, err := loadActiveReviewers(ctx, tx, prID)
+	if err != nil {
+		return err
+	}
+	if err := s.auditReviewerChange(ctx, tx, prID, authorID, before, after); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *Storage) RecordTeamDeactivated(ctx context.Context, teamName string, deactivated []string, result map[string]interface{}) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
 
-		return true, nil
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeEvent(ctx, tx, teamDeactivatedEvent(teamName, result)); err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditTeamBulkDeactivated, models.EntityTeam, teamName, nil, result)
+	audit.TeamName = teamName
+	audit.UserIDs = deactivated
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
 	}
 
-	return false, nil
+	return tx.Commit()
 }
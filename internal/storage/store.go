@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/models"
+)
+
+var (
+	ErrPRExists          = errors.New("pull request already exists")
+	ErrInvalidTransition = errors.New("invalid pull request status transition")
+)
+
+type Store interface {
+	CreateTeam(ctx context.Context, team models.Team) error
+	GetTeam(ctx context.Context, teamName string) (*models.Team, error)
+	GetTeamSettings(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	GetTeamSettingsForUpdate(ctx context.Context, teamName string) (*models.TeamSettings, error)
+	UpdateTeamSettings(ctx context.Context, settings models.TeamSettings) error
+	GetUserByID(ctx context.Context, userID string) (*models.User, error)
+	GetUserByLogin(ctx context.Context, provider, login string) (*models.User, error)
+	GetUserTeam(ctx context.Context, userID string) (string, error)
+	UpdateUserActive(ctx context.Context, userID string, isActive bool) error
+	GetActiveTeamMembers(ctx context.Context, teamName, excludeUserID string) ([]models.User, error)
+	GetActiveUsersOutsideTeam(ctx context.Context, teamName, excludeUserID string) ([]models.User, error)
+	GetOpenReviewCounts(ctx context.Context, userIDs []string) (map[string]int, error)
+	// GetReviewLimits returns the effective open review limit of each listed
+	// user that has one, either their own or their team's default.
+	GetReviewLimits(ctx context.Context, userIDs []string) (map[string]int, error)
+	SetUserMaxOpenReviews(ctx context.Context, userID string, limit *int) error
+	SetUserRole(ctx context.Context, userID, role string) error
+	AddAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (*models.AvailabilityPeriod, error)
+	ListAvailabilityPeriods(ctx context.Context, userID string) ([]models.AvailabilityPeriod, error)
+	// UpdateAvailabilityPeriod changes the dates, reason and reassign flag of
+	// a period, or returns sql.ErrNoRows if there is none with its id.
+	UpdateAvailabilityPeriod(ctx context.Context, period models.AvailabilityPeriod) (*models.AvailabilityPeriod, error)
+	DeleteAvailabilityPeriod(ctx context.Context, id int64) error
+	// GetUnavailableUsers returns the period each listed user is away on at
+	// the given time; users who are available are left out.
+	GetUnavailableUsers(ctx context.Context, userIDs []string, at time.Time) (map[string]models.AvailabilityPeriod, error)
+	// DueReviewHandoffs lists periods under way at the given time whose
+	// reviews should be reassigned and have not been yet.
+	DueReviewHandoffs(ctx context.Context, at time.Time) ([]models.AvailabilityPeriod, error)
+	MarkReviewsHandedOff(ctx context.Context, id int64, at time.Time) error
+	// AddReviewerExclusion stores the exclusion, replacing the reason of an
+	// existing one for the same pair.
+	AddReviewerExclusion(ctx context.Context, exclusion models.ReviewerExclusion) (*models.ReviewerExclusion, error)
+	// ListReviewerExclusions lists the exclusions naming the user on either
+	// side, or all of them for an empty user id.
+	ListReviewerExclusions(ctx context.Context, userID string) ([]models.ReviewerExclusion, error)
+	// DeleteReviewerExclusion returns sql.ErrNoRows if the pair has none.
+	DeleteReviewerExclusion(ctx context.Context, reviewerID, authorID string) error
+	// GetReviewerExclusions returns the exclusion barring each listed user
+	// from reviewing the author; users who are not barred are left out.
+	GetReviewerExclusions(ctx context.Context, authorID string, userIDs []string) (map[string]models.ReviewerExclusion, error)
+	// GetRepeatReviewers lists the users assigned to each of the author's
+	// latest n PRs with reviewers, not counting skipPRID. It is empty while
+	// the author has fewer such PRs.
+	GetRepeatReviewers(ctx context.Context, authorID, skipPRID string, n int) ([]string, error)
+	CreatePR(ctx context.Context, pr models.PullRequest) error
+	GetPRByID(ctx context.Context, prID string) (*models.PullRequest, error)
+	GetPRForUpdate(ctx context.Context, prID string) (*models.PullRequest, error)
+	UpdatePRStatus(ctx context.Context, prID string, status string) error
+	UpdatePRReviewers(ctx context.Context, prID string, reviewers []string) error
+	ReplacePRReviewer(ctx context.Context, prID, oldUserID, newUserID string) error
+	GetPRReviewerHistory(ctx context.Context, prID string) ([]models.ReviewerAssignment, error)
+	// AddAssignmentRecord logs a reviewer selection made for a PR.
+	AddAssignmentRecord(ctx context.Context, record models.AssignmentRecord) error
+	// TeamRotation is where the team's last recorded selection left its round
+	// robin, or "" before the first one.
+	TeamRotation(ctx context.Context, teamName string) (string, error)
+	// TeamRotationForUpdate is TeamRotation with the team locked until the
+	// transaction ends, so selections for one team take turns.
+	TeamRotationForUpdate(ctx context.Context, teamName string) (string, error)
+	// ListAssignmentRecords lists the selections made for a PR, oldest first.
+	ListAssignmentRecords(ctx context.Context, prID string) ([]models.AssignmentRecord, error)
+	SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict, comment string) error
+	GetPRsByReviewer(ctx context.Context, userID string) ([]models.PullRequest, error)
+	GetReviewStats(ctx context.Context) (map[string]interface{}, error)
+	// DeactivateTeamUsers deactivates every member of the team and lists the
+	// open PRs one of them was reviewing.
+	DeactivateTeamUsers(ctx context.Context, teamName string) (deactivated []string, affectedPRs []string, err error)
+	// ReplaceDeactivatedReviewers replaces each removed reviewer of the PR by
+	// the added one at the same index; removed reviewers left over are
+	// dropped and added ones left over join the PR.
+	ReplaceDeactivatedReviewers(ctx context.Context, prID string, removed, added []string) error
+	// RecordTeamDeactivated emits the event and audit entry of a bulk
+	// deactivation with its result.
+	RecordTeamDeactivated(ctx context.Context, teamName string, deactivated []string, result map[string]interface{}) error
+	CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (*models.WebhookSubscription, error)
+	ListWebhookSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteWebhookSubscription(ctx context.Context, id int64) error
+	AddDeadLetter(ctx context.Context, letter models.DeadLetter) error
+	ListDeadLetters(ctx context.Context) ([]models.DeadLetter, error)
+	PendingEvents(ctx context.Context, owner string, limit int, lease time.Duration) ([]events.PendingEvent, error)
+	EventDeliveries(ctx context.Context, eventID string) ([]string, error)
+	MarkEventDelivered(ctx context.Context, eventID, sink string) error
+	MarkEventPublished(ctx context.Context, eventID string) error
+	ListAuditEvents(ctx context.Context, filter models.AuditFilter) ([]models.AuditEvent, error)
+
+	// ClaimIdempotencyKey reserves key for the caller for lease and returns
+	// nil, or returns the unexpired record already holding the key.
+	ClaimIdempotencyKey(ctx context.Context, key, requestHash string, lease time.Duration) (*models.IdempotentResponse, error)
+	// SaveIdempotentResponse stores the response for a claimed key and keeps
+	// it for ttl.
+	SaveIdempotentResponse(ctx context.Context, response models.IdempotentResponse, ttl time.Duration) error
+	// ReleaseIdempotencyKey drops a claim that has no stored response, so
+	// the request can be retried.
+	ReleaseIdempotencyKey(ctx context.Context, key string) error
+
+	// InTx runs fn against a view of the store that is isolated from
+	// concurrent callers. Both stores roll fn's writes back when it returns
+	// an error.
+	InTx(ctx context.Context, fn func(tx Store) error) error
+
+	// WithActor returns a view of the store that records its changes in the
+	// audit log as made by actor.
+	WithActor(actor string) Store
+}
+
+var (
+	_ Store = (*Storage)(nil)
+	_ Store = (*MemoryStorage)(nil)
+)
+
+type userStat struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+	Count    int    `json:"assignment_count"`
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"context"
+	"strconv"
+
+	"pr-reviewer-service/internal/models"
+
+	"github.com/lib/pq"
+)
+
+func (s *Storage) CreateWebhookSubscription(ctx context.Context, sub models.WebhookSubscription) (_ *models.WebhookSubscription, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO webhook_subscriptions (url, secret, events)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, sub.URL, sub.Secret, pq.Array(sub.Events)).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := newAudit(models.AuditSubscriptionCreated, models.EntitySubscription, strconv.FormatInt(sub.ID, 10), nil,
+		redactSubscription(sub))
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return nil, err
+	}
+
+	return &sub, tx.Commit()
+}
+
+func (s *Storage) ListWebhookSubscriptions(ctx context.Context) (_ []models.WebhookSubscription, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT id, url, secret, events, created_at
+		FROM webhook_subscriptions
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subs := []models.WebhookSubscription{}
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		err := rows.Scan(&sub.ID, &sub.URL, &sub.Secret, (*pq.StringArray)(&sub.Events), &sub.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+func (s *Storage) DeleteWebhookSubscription(ctx context.Context, id int64) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var sub models.WebhookSubscription
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM webhook_subscriptions WHERE id = $1
+		RETURNING id, url, events, created_at
+	`, id).Scan(&sub.ID, &sub.URL, (*pq.StringArray)(&sub.Events), &sub.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	audit := newAudit(models.AuditSubscriptionDeleted, models.EntitySubscription, strconv.FormatInt(id, 10), sub, nil)
+	if err := s.writeAudit(ctx, tx, audit); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// redactSubscription drops the secret so it never reaches the audit log.
+func redactSubscription(sub models.WebhookSubscription) models.WebhookSubscription {
+	sub.Secret = ""
+	return sub
+}
+
+func (s *Storage) AddDeadLetter(ctx context.Context, letter models.DeadLetter) (err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	_, err = s.q().ExecContext(ctx, `
+		INSERT INTO webhook_dead_letters (subscription_id, event_id, event_type, payload, attempts, last_error)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, letter.SubscriptionID, letter.EventID, letter.EventType, letter.Payload, letter.Attempts, letter.LastError)
+	return err
+}
+
+func (s *Storage) ListDeadLetters(ctx context.Context) (_ []models.DeadLetter, err error) {
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	rows, err := s.q().QueryContext(ctx, `
+		SELECT id, subscription_id, event_id, event_type, payload, attempts, last_error, failed_at
+		FROM webhook_dead_letters
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []models.DeadLetter{}
+	for rows.Next() {
+		var letter models.DeadLetter
+		err := rows.Scan(&letter.ID, &letter.SubscriptionID, &letter.EventID, &letter.EventType,
+			&letter.Payload, &letter.Attempts, &letter.LastError, &letter.FailedAt)
+		if err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+
+	return letters, rows.Err()
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// txn is the transaction a storage call writes through. Calls made on an
+// InTx view join the enclosing transaction, which only InTx commits or rolls
+// back.
+type txn struct {
+	*sql.Tx
+	owned bool
+}
+
+func (t txn) Commit() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Commit()
+}
+
+func (t txn) Rollback() error {
+	if !t.owned {
+		return nil
+	}
+	return t.Tx.Rollback()
+}
+
+func (s *Storage) begin(ctx context.Context) (txn, error) {
+	if s.tx != nil {
+		return txn{Tx: s.tx}, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	return txn{Tx: tx, owned: true}, err
+}
+
+// q returns the handle reads go through, so that reads made inside InTx see
+// the transaction's own writes and locks.
+func (s *Storage) q() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// InTx runs fn against a view of the storage bound to one transaction. It is
+// committed when fn returns nil and rolled back otherwise.
+func (s *Storage) InTx(ctx context.Context, fn func(tx Store) error) (err error) {
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	ctx, done := s.withTimeout(ctx, &err)
+	defer done()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	view := *s
+	view.tx = tx
+	if err := fn(&view); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
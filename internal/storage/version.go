@@ -0,0 +1,16 @@
+package storage
+
+import "context"
+
+// Every change to a PR or a team, including its reviewers, reviews and
+// members, bumps the row's version so clients can detect lost updates.
+
+func bumpPRVersion(ctx context.Context, q querier, prID string) error {
+	_, err := q.ExecContext(ctx, `UPDATE pull_requests SET version = version + 1 WHERE pull_request_id = $1`, prID)
+	return err
+}
+
+func bumpTeamVersion(ctx context.Context, q querier, teamName string) error {
+	_, err := q.ExecContext(ctx, `UPDATE teams SET version = version + 1 WHERE team_name = $1`, teamName)
+	return err
+}
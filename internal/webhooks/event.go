@@ -0,0 +1,56 @@
+package webhooks
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"pr-reviewer-service/internal/models"
+	"strconv"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+const (
+	KindOpened          = "opened"
+	KindReadyForReview  = "ready_for_review"
+	KindClosed          = "closed"
+	KindMerged          = "merged"
+	KindReopened        = "reopened"
+	KindReviewSubmitted = "review_submitted"
+)
+
+var ErrInvalidPayload = errors.New("invalid webhook payload")
+
+// Event is a forge-neutral description of something that happened to a pull
+// request. Adapters translate provider payloads into events, and the handlers
+// apply them through the same logic the HTTP API uses.
+type Event struct {
+	Provider        string
+	Kind            string
+	PullRequestID   string
+	PullRequestName string
+	AuthorLogin     string
+	Draft           bool
+
+	ReviewerLogin string
+	Verdict       string
+	Comment       string
+}
+
+// PullRequestID builds the service-side id for a forge pull request, which is
+// only unique within its repository. A repository path too long for the id is
+// cut and suffixed with its hash, so the id stays stable and unique.
+func PullRequestID(repository string, number int) string {
+	suffix := "#" + strconv.Itoa(number)
+	if len(repository)+len(suffix) <= models.MaxPullRequestIDLength {
+		return repository + suffix
+	}
+
+	sum := sha256.Sum256([]byte(repository))
+	hash := "~" + hex.EncodeToString(sum[:8])
+	return repository[:models.MaxPullRequestIDLength-len(hash)-len(suffix)] + hash + suffix
+}
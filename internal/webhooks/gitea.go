@@ -0,0 +1,96 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"pr-reviewer-service/internal/models"
+)
+
+type giteaPayload struct {
+	Action      string            `json:"action"`
+	PullRequest githubPullRequest `json:"pull_request"`
+	Review      struct {
+		Type    string `json:"type"`
+		Content string `json:"content"`
+	} `json:"review"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Sender githubUser `json:"sender"`
+}
+
+var giteaReviewEvents = map[string]string{
+	"pull_request_review_approved": models.VerdictApproved,
+	"pull_request_review_rejected": models.VerdictChangesRequested,
+	"pull_request_review_comment":  models.VerdictCommented,
+}
+
+// VerifyGiteaSignature checks the X-Gitea-Signature header, a bare hex
+// HMAC-SHA256 of the body.
+func VerifyGiteaSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseGitea translates a Gitea delivery into an Event. eventType is the
+// X-Gitea-Event header. Gitea's pull request payload mirrors GitHub's, but
+// reviews arrive as separate event types rather than a review state.
+func ParseGitea(eventType string, body []byte) (*Event, error) {
+	verdict, isReview := giteaReviewEvents[eventType]
+	if eventType != "pull_request" && !isReview {
+		return nil, nil
+	}
+
+	var payload giteaPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, ErrInvalidPayload
+	}
+
+	event := &Event{
+		Provider:        ProviderGitea,
+		PullRequestID:   PullRequestID(payload.Repository.FullName, payload.PullRequest.Number),
+		PullRequestName: payload.PullRequest.Title,
+		AuthorLogin:     payload.PullRequest.User.Login,
+		Draft:           payload.PullRequest.Draft,
+	}
+
+	if isReview {
+		event.Kind = KindReviewSubmitted
+		event.ReviewerLogin = payload.Sender.Login
+		event.Verdict = verdict
+		event.Comment = payload.Review.Content
+		return event, nil
+	}
+
+	switch payload.Action {
+	case "opened":
+		event.Kind = KindOpened
+	case "reopened":
+		event.Kind = KindReopened
+	case "closed":
+		event.Kind = KindClosed
+		if payload.PullRequest.Merged {
+			event.Kind = KindMerged
+		}
+	default:
+		return nil, nil
+	}
+
+	return event, nil
+}
@@ -0,0 +1,121 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"pr-reviewer-service/internal/models"
+)
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubPullRequest struct {
+	Number int        `json:"number"`
+	Title  string     `json:"title"`
+	Draft  bool       `json:"draft"`
+	Merged bool       `json:"merged"`
+	User   githubUser `json:"user"`
+}
+
+type githubPayload struct {
+	Action      string            `json:"action"`
+	PullRequest githubPullRequest `json:"pull_request"`
+	Review      struct {
+		State string     `json:"state"`
+		Body  string     `json:"body"`
+		User  githubUser `json:"user"`
+	} `json:"review"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+var githubVerdicts = map[string]string{
+	"approved":          models.VerdictApproved,
+	"changes_requested": models.VerdictChangesRequested,
+	"commented":         models.VerdictCommented,
+}
+
+// VerifyGitHubSignature checks the X-Hub-Signature-256 header of a delivery
+// against the HMAC-SHA256 of its body.
+func VerifyGitHubSignature(secret string, body []byte, signature string) bool {
+	if secret == "" {
+		return false
+	}
+
+	hexDigest, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return false
+	}
+	got, err := hex.DecodeString(hexDigest)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// ParseGitHub translates a GitHub delivery into an Event. eventType is the
+// X-GitHub-Event header. A nil event with a nil error means the delivery is
+// valid but irrelevant to the service.
+func ParseGitHub(eventType string, body []byte) (*Event, error) {
+	if eventType != "pull_request" && eventType != "pull_request_review" {
+		return nil, nil
+	}
+
+	var payload githubPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	if payload.Repository.FullName == "" || payload.PullRequest.Number == 0 {
+		return nil, ErrInvalidPayload
+	}
+
+	event := &Event{
+		Provider:        ProviderGitHub,
+		PullRequestID:   PullRequestID(payload.Repository.FullName, payload.PullRequest.Number),
+		PullRequestName: payload.PullRequest.Title,
+		AuthorLogin:     payload.PullRequest.User.Login,
+		Draft:           payload.PullRequest.Draft,
+	}
+
+	if eventType == "pull_request_review" {
+		if payload.Action != "submitted" {
+			return nil, nil
+		}
+		verdict, ok := githubVerdicts[strings.ToLower(payload.Review.State)]
+		if !ok {
+			return nil, nil
+		}
+		event.Kind = KindReviewSubmitted
+		event.ReviewerLogin = payload.Review.User.Login
+		event.Verdict = verdict
+		event.Comment = payload.Review.Body
+		return event, nil
+	}
+
+	switch payload.Action {
+	case "opened":
+		event.Kind = KindOpened
+	case "ready_for_review":
+		event.Kind = KindReadyForReview
+	case "reopened":
+		event.Kind = KindReopened
+	case "closed":
+		event.Kind = KindClosed
+		if payload.PullRequest.Merged {
+			event.Kind = KindMerged
+		}
+	default:
+		return nil, nil
+	}
+
+	return event, nil
+}
@@ -0,0 +1,102 @@
+package webhooks
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+
+	"pr-reviewer-service/internal/models"
+)
+
+type gitlabPayload struct {
+	ObjectKind string `json:"object_kind"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID            int    `json:"iid"`
+		Title          string `json:"title"`
+		Action         string `json:"action"`
+		Draft          bool   `json:"draft"`
+		WorkInProgress bool   `json:"work_in_progress"`
+	} `json:"object_attributes"`
+	Changes struct {
+		Draft          gitlabChange `json:"draft"`
+		WorkInProgress gitlabChange `json:"work_in_progress"`
+	} `json:"changes"`
+}
+
+// gitlabChange is a field change reported with an "update" action. Previous
+// and Current are nil when the field did not change.
+type gitlabChange struct {
+	Previous *bool `json:"previous"`
+	Current  *bool `json:"current"`
+}
+
+// cleared reports whether the flag went from true to false.
+func (c gitlabChange) cleared() bool {
+	return c.Previous != nil && *c.Previous && c.Current != nil && !*c.Current
+}
+
+// VerifyGitLabToken compares the X-Gitlab-Token header with the configured
+// secret. GitLab sends the secret as is instead of signing the body.
+func VerifyGitLabToken(secret, token string) bool {
+	if secret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(token)) == 1
+}
+
+// ParseGitLab translates a GitLab delivery into an Event. eventType is the
+// X-Gitlab-Event header; only merge request hooks are relevant.
+func ParseGitLab(eventType string, body []byte) (*Event, error) {
+	if eventType != "Merge Request Hook" {
+		return nil, nil
+	}
+
+	var payload gitlabPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, ErrInvalidPayload
+	}
+	attrs := payload.ObjectAttributes
+	if payload.ObjectKind != "merge_request" || payload.Project.PathWithNamespace == "" || attrs.IID == 0 {
+		return nil, ErrInvalidPayload
+	}
+
+	// GitLab only reports the user who triggered the hook, which is the
+	// author for "open" and the reviewer for "approved".
+	event := &Event{
+		Provider:        ProviderGitLab,
+		PullRequestID:   PullRequestID(payload.Project.PathWithNamespace, attrs.IID),
+		PullRequestName: attrs.Title,
+		Draft:           attrs.Draft || attrs.WorkInProgress,
+	}
+
+	switch attrs.Action {
+	case "open":
+		event.Kind = KindOpened
+		event.AuthorLogin = payload.User.Username
+	case "close":
+		event.Kind = KindClosed
+	case "reopen":
+		event.Kind = KindReopened
+	case "update":
+		// Older GitLab versions report the draft flag as work_in_progress.
+		if !payload.Changes.Draft.cleared() && !payload.Changes.WorkInProgress.cleared() {
+			return nil, nil
+		}
+		event.Kind = KindReadyForReview
+	case "merge":
+		event.Kind = KindMerged
+	case "approved":
+		event.Kind = KindReviewSubmitted
+		event.ReviewerLogin = payload.User.Username
+		event.Verdict = models.VerdictApproved
+	default:
+		return nil, nil
+	}
+
+	return event, nil
+}
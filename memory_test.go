@@ -0,0 +1,461 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func postJSON(t *testing.T, url string, body interface{}) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	jsonData, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	resp.Body.Close()
+	return resp, decoded
+}
+
+func addMemoryTeam(t *testing.T, serverURL, teamName string, userIDs ...string) {
+	t.Helper()
+
+	members := []map[string]interface{}{}
+	for _, id := range userIDs {
+		members = append(members, map[string]interface{}{"user_id": id, "username": id, "is_active": true})
+	}
+	resp, _ := postJSON(t, serverURL+"/team/add", map[string]interface{}{
+		"team_name": teamName,
+		"members":   members,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+func TestMemory_TeamLifecycle(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "mem-team", "mem-1", "mem-2", "mem-3")
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{"team_name": "mem-team"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err := http.Get(server.URL + "/team/get?team_name=mem-team")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var team map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&team))
+	assert.Equal(t, "mem-team", team["team_name"])
+	assert.Len(t, team["members"], 3)
+}
+
+func TestMemory_PRLifecycle(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "mem-team", "author", "rev-1", "rev-2", "rev-3")
+
+	prData := map[string]interface{}{
+		"pull_request_id":   "mem-pr-1",
+		"pull_request_name": "Memory PR",
+		"author_id":         "author",
+	}
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", prData)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	pr := body["pr"].(map[string]interface{})
+	reviewers := pr["assigned_reviewers"].([]interface{})
+	assert.Equal(t, "OPEN", pr["status"])
+	assert.Len(t, reviewers, 2)
+	assert.NotContains(t, reviewers, "author")
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", prData)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "PR_EXISTS", body["error"].(map[string]interface{})["code"])
+
+	oldReviewer := reviewers[0].(string)
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "mem-pr-1",
+		"old_user_id":     oldReviewer,
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, oldReviewer, body["replaced_by"])
+	assert.NotContains(t, body["pr"].(map[string]interface{})["assigned_reviewers"], oldReviewer)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "mem-pr-1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MERGED", body["pr"].(map[string]interface{})["status"])
+
+	resp, _ = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "mem-pr-1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "mem-pr-1",
+		"old_user_id":     body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})[0],
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "PR_MERGED", body["error"].(map[string]interface{})["code"])
+}
+
+func TestMemory_BulkDeactivate(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "backend", "be-author", "be-rev")
+	addMemoryTeam(t, server.URL, "frontend", "fe-1", "fe-2", "fe-3")
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "mem-pr-bulk",
+		"pull_request_name": "Bulk PR",
+		"author_id":         "be-author",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []interface{}{"be-rev"}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+
+	resp, _ = postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "backend"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pr, err := store.GetPRByID(context.Background(), "mem-pr-bulk")
+	assert.NoError(t, err)
+	assert.Empty(t, pr.AssignedReviewers)
+
+	resp, err = http.Get(server.URL + "/users/getReview?user_id=be-rev")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMemory_ReviewerHistory(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "hist", "author", "rev-1", "rev-2", "rev-3")
+	reviewers := createPR(t, server.URL, "hist-pr", "author")
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "hist-pr",
+		"old_user_id":     reviewers[0],
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	replacedBy := body["replaced_by"]
+
+	resp, err := http.Get(server.URL + "/pullRequest/get?pull_request_id=hist-pr")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	history := body["reviewer_history"].([]interface{})
+	assert.Len(t, history, 3)
+
+	first := history[0].(map[string]interface{})
+	assert.Equal(t, reviewers[0], first["user_id"])
+	assert.Equal(t, "REPLACED", first["state"])
+	assert.Equal(t, replacedBy, first["replaced_by"])
+
+	last := history[2].(map[string]interface{})
+	assert.Equal(t, replacedBy, last["user_id"])
+	assert.Equal(t, "ASSIGNED", last["state"])
+	assert.Equal(t, "reassign", last["assigned_by"])
+
+	assert.Equal(t, []interface{}{replacedBy, reviewers[1]}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+}
+
+func TestMemory_ReviewVerdictsGateMerge(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "verdicts", "author", "rev-1", "rev-2")
+	updateSettings(t, server.URL, map[string]interface{}{"team_name": "verdicts", "required_approvals": 2})
+	reviewers := createPR(t, server.URL, "verdict-pr", "author")
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "verdict-pr",
+		"reviewer_id":     "author",
+		"verdict":         "APPROVED",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ASSIGNED", body["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "verdict-pr",
+		"reviewer_id":     reviewers[0],
+		"verdict":         "LGTM",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_VERDICT", body["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "verdict-pr",
+		"reviewer_id":     reviewers[0],
+		"verdict":         "APPROVED",
+		"comment":         "looks good",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	reviews := body["pr"].(map[string]interface{})["reviews"].([]interface{})
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, "APPROVED", reviews[0].(map[string]interface{})["verdict"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "verdict-pr"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "APPROVALS_REQUIRED", body["error"].(map[string]interface{})["code"])
+
+	postJSON(t, server.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "verdict-pr",
+		"reviewer_id":     reviewers[1],
+		"verdict":         "APPROVED",
+	})
+
+	resp, _ = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "verdict-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMemory_FailedTransactionRollsBack(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "rollback", "rb-author", "rb-1", "rb-2", "rb-3")
+	updateSettings(t, server.URL, map[string]interface{}{"team_name": "rollback", "max_reviewers": 1})
+	reviewers := createPR(t, server.URL, "rb-pr", "rb-author")
+	assert.Len(t, reviewers, 1)
+
+	ctx := context.Background()
+	member := models.User{UserID: "rb-3", Username: "rb-3", IsActive: true, Logins: map[string]string{"github": "rb-3-gh"}}
+	assert.NoError(t, store.CreateTeam(ctx, models.Team{TeamName: "rollback", Members: []models.User{member}}))
+
+	before, err := store.ListAuditEvents(ctx, models.AuditFilter{})
+	assert.NoError(t, err)
+
+	replacement := "rb-2"
+	if reviewers[0] == replacement {
+		replacement = "rb-3"
+	}
+	err = store.InTx(ctx, func(tx storage.Store) error {
+		assert.NoError(t, tx.UpdateUserActive(ctx, "rb-1", false))
+		assert.NoError(t, tx.ReplaceDeactivatedReviewers(ctx, "rb-pr", []string{reviewers[0].(string)}, []string{replacement}))
+		member.Logins = map[string]string{"github": "rb-3-new", "gitlab": "rb-3-gl"}
+		assert.NoError(t, tx.CreateTeam(ctx, models.Team{TeamName: "rollback", Members: []models.User{member}}))
+		return errors.New("abort")
+	})
+	assert.EqualError(t, err, "abort")
+
+	user, err := store.GetUserByID(ctx, "rb-1")
+	assert.NoError(t, err)
+	assert.True(t, user.IsActive)
+
+	user, err = store.GetUserByID(ctx, "rb-3")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"github": "rb-3-gh"}, user.Logins)
+	user, err = store.GetUserByLogin(ctx, "github", "rb-3-gh")
+	assert.NoError(t, err)
+	if assert.NotNil(t, user) {
+		assert.Equal(t, "rb-3", user.UserID)
+	}
+
+	pr, err := store.GetPRByID(ctx, "rb-pr")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{reviewers[0].(string)}, pr.AssignedReviewers)
+	assert.Equal(t, int64(1), pr.Version)
+
+	after, err := store.ListAuditEvents(ctx, models.AuditFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+}
+
+// teamlessStore fails author team lookups with a database error.
+type teamlessStore struct {
+	storage.Store
+}
+
+func (s teamlessStore) WithActor(actor string) storage.Store {
+	return teamlessStore{s.Store.WithActor(actor)}
+}
+
+func (s teamlessStore) InTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	return s.Store.InTx(ctx, func(tx storage.Store) error {
+		return fn(teamlessStore{tx})
+	})
+}
+
+func (s teamlessStore) GetUserTeam(ctx context.Context, userID string) (string, error) {
+	return "", errors.New("connection reset")
+}
+
+func TestMemory_MergeFailsWhenAuthorTeamLookupFails(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "strict", "strict-author", "strict-rev")
+	updateSettings(t, server.URL, map[string]interface{}{"team_name": "strict", "required_approvals": 1})
+	createPR(t, server.URL, "strict-pr", "strict-author")
+
+	failing := setupTestServer(teamlessStore{store})
+	defer failing.Close()
+
+	// Without the author's team the approval rule is unknown, so the merge
+	// must not fall back to the default settings.
+	resp, _ := postJSON(t, failing.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "strict-pr"})
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	pr, err := store.GetPRByID(context.Background(), "strict-pr")
+	assert.NoError(t, err)
+	assert.Equal(t, "OPEN", pr.Status)
+}
+
+func TestMemory_PRStatusMachine(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "states", "author", "rev-1", "rev-2")
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "state-pr",
+		"pull_request_name": "state-pr",
+		"author_id":         "author",
+		"draft":             true,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "DRAFT", pr["status"])
+	assert.Empty(t, pr["assigned_reviewers"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "INVALID_TRANSITION", body["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/ready", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr = body["pr"].(map[string]interface{})
+	assert.Equal(t, "OPEN", pr["status"])
+	assert.Len(t, pr["assigned_reviewers"], 2)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/close", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "CLOSED", body["pr"].(map[string]interface{})["status"])
+	assert.NotNil(t, body["pr"].(map[string]interface{})["closedAt"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "state-pr",
+		"old_user_id":     "rev-1",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "PR_NOT_OPEN", body["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/ready", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "INVALID_TRANSITION", body["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reopen", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "REOPENED", body["pr"].(map[string]interface{})["status"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MERGED", body["pr"].(map[string]interface{})["status"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/close", map[string]interface{}{"pull_request_id": "state-pr"})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "INVALID_TRANSITION", body["error"].(map[string]interface{})["code"])
+}
+
+func TestMemory_ReopenedDraftGetsReviewers(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "reopen-team", "reopen-author", "reopen-1", "reopen-2")
+	resp, _ := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "reopen-pr",
+		"pull_request_name": "reopen-pr",
+		"author_id":         "reopen-author",
+		"draft":             true,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = postJSON(t, server.URL+"/pullRequest/close", map[string]interface{}{"pull_request_id": "reopen-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The draft never had reviewers, so reopening it must assign them.
+	resp, body := postJSON(t, server.URL+"/pullRequest/reopen", map[string]interface{}{"pull_request_id": "reopen-pr"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "REOPENED", pr["status"])
+	assert.ElementsMatch(t, []interface{}{"reopen-1", "reopen-2"}, pr["assigned_reviewers"])
+}
+
+// unassigningStore replaces the reviewer just before their verdict is saved,
+// as a reassign landing between the check and the write would.
+type unassigningStore struct {
+	storage.Store
+}
+
+func (s unassigningStore) WithActor(actor string) storage.Store {
+	return unassigningStore{s.Store.WithActor(actor)}
+}
+
+func (s unassigningStore) InTx(ctx context.Context, fn func(tx storage.Store) error) error {
+	return s.Store.InTx(ctx, func(tx storage.Store) error {
+		return fn(unassigningStore{tx})
+	})
+}
+
+func (s unassigningStore) SetReviewVerdict(ctx context.Context, prID, reviewerID, verdict, comment string) error {
+	if err := s.Store.ReplacePRReviewer(ctx, prID, reviewerID, "late-rev"); err != nil {
+		return err
+	}
+	return s.Store.SetReviewVerdict(ctx, prID, reviewerID, verdict, comment)
+}
+
+func TestMemory_ReviewByReplacedReviewerIsNotAssigned(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "late", "late-author", "late-rev-1", "late-rev-2")
+	createPR(t, server.URL, "late-pr", "late-author")
+
+	failing := setupTestServer(unassigningStore{store})
+	defer failing.Close()
+
+	resp, body := postJSON(t, failing.URL+"/pullRequest/review", map[string]interface{}{
+		"pull_request_id": "late-pr",
+		"reviewer_id":     "late-rev-1",
+		"verdict":         "APPROVED",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "NOT_ASSIGNED", errorCode(body))
+
+	// The replacement rolls back with the failed review.
+	pr, err := store.GetPRByID(context.Background(), "late-pr")
+	assert.NoError(t, err)
+	assert.Contains(t, pr.AssignedReviewers, "late-rev-1")
+	assert.Empty(t, pr.Reviews)
+}
+
+func TestMemory_TeamAddLeavesReadLoginsAlone(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	ctx := context.Background()
+
+	member := models.User{UserID: "lg-1", Username: "lg-1", IsActive: true, Logins: map[string]string{"github": "lg-gh"}}
+	assert.NoError(t, store.CreateTeam(ctx, models.Team{TeamName: "logins", Members: []models.User{member}}))
+	read, err := store.GetUserByID(ctx, "lg-1")
+	assert.NoError(t, err)
+
+	member.Logins = map[string]string{"gitlab": "lg-gl"}
+	assert.NoError(t, store.CreateTeam(ctx, models.Team{TeamName: "logins", Members: []models.User{member}}))
+
+	assert.Equal(t, map[string]string{"github": "lg-gh"}, read.Logins, "a user already read keeps its logins")
+	user, err := store.GetUserByID(ctx, "lg-1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"github": "lg-gh", "gitlab": "lg-gl"}, user.Logins)
+}
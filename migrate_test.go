@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"pr-reviewer-service/internal/migrate"
+	"pr-reviewer-service/migrations"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmbeddedMigrationsAreContiguous(t *testing.T) {
+	list, err := migrate.Load(migrations.FS)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, list)
+
+	for i, m := range list {
+		assert.Equal(t, i+1, m.Version, "migration versions must have no gaps")
+		assert.NotEmpty(t, strings.TrimSpace(m.Up), "%03d_%s up", m.Version, m.Name)
+		assert.NotEmpty(t, strings.TrimSpace(m.Down), "%03d_%s has no down script", m.Version, m.Name)
+	}
+}
+
+func TestLoadMigrationsRejectsBadNames(t *testing.T) {
+	_, err := migrate.Load(fstest.MapFS{
+		"init.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err)
+
+	_, err = migrate.Load(fstest.MapFS{
+		"001_init.down.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.Error(t, err, "a down script without an up script is invalid")
+}
@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS assignment_strategy VARCHAR(20) NOT NULL DEFAULT 'random';
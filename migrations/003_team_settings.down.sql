@@ -0,0 +1,5 @@
+ALTER TABLE teams DROP CONSTRAINT IF EXISTS teams_reviewers_range;
+
+ALTER TABLE teams DROP COLUMN IF EXISTS allow_cross_team_fallback;
+ALTER TABLE teams DROP COLUMN IF EXISTS max_reviewers;
+ALTER TABLE teams DROP COLUMN IF EXISTS min_reviewers;
@@ -0,0 +1,5 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS min_reviewers INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS max_reviewers INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS allow_cross_team_fallback BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE teams ADD CONSTRAINT teams_reviewers_range CHECK (min_reviewers >= 0 AND max_reviewers >= min_reviewers);
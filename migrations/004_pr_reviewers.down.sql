@@ -0,0 +1,10 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS assigned_reviewers JSONB;
+
+UPDATE pull_requests pr
+SET assigned_reviewers = COALESCE((
+    SELECT jsonb_agg(r.user_id ORDER BY r.position)
+    FROM pr_reviewers r
+    WHERE r.pull_request_id = pr.pull_request_id AND r.state = 'ASSIGNED'
+), '[]'::jsonb);
+
+DROP TABLE IF EXISTS pr_reviewers;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS pr_reviewers (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(50) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(user_id),
+    position INTEGER NOT NULL DEFAULT 0,
+    assigned_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    assigned_by VARCHAR(50) NOT NULL DEFAULT 'system',
+    state VARCHAR(20) NOT NULL DEFAULT 'ASSIGNED',
+    replaced_by VARCHAR(50) NULL REFERENCES users(user_id),
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS pr_reviewers_active_uniq
+    ON pr_reviewers (pull_request_id, user_id) WHERE state = 'ASSIGNED';
+CREATE INDEX IF NOT EXISTS pr_reviewers_user_active_idx
+    ON pr_reviewers (user_id) WHERE state = 'ASSIGNED';
+CREATE INDEX IF NOT EXISTS pr_reviewers_pr_idx
+    ON pr_reviewers (pull_request_id, position);
+
+INSERT INTO pr_reviewers (pull_request_id, user_id, position, assigned_at, assigned_by, state)
+SELECT pr.pull_request_id, r.user_id, r.ord - 1, COALESCE(pr.created_at, CURRENT_TIMESTAMP), 'backfill', 'ASSIGNED'
+FROM pull_requests pr
+CROSS JOIN LATERAL jsonb_array_elements_text(COALESCE(pr.assigned_reviewers, '[]'::jsonb)) WITH ORDINALITY AS r(user_id, ord)
+WHERE EXISTS (SELECT 1 FROM users u WHERE u.user_id = r.user_id)
+AND NOT EXISTS (SELECT 1 FROM pr_reviewers existing WHERE existing.pull_request_id = pr.pull_request_id);
+
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS assigned_reviewers;
@@ -0,0 +1,5 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS required_approvals;
+
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS reviewed_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS verdict_comment;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS verdict;
@@ -0,0 +1,5 @@
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS verdict VARCHAR(20) NULL;
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS verdict_comment TEXT NULL;
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP NULL;
+
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS required_approvals INTEGER NOT NULL DEFAULT 0;
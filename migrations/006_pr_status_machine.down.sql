@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS pull_requests_status_idx;
+
+ALTER TABLE pull_requests DROP CONSTRAINT IF EXISTS pull_requests_status_check;
+ALTER TABLE pull_requests ALTER COLUMN status DROP NOT NULL;
+ALTER TABLE pull_requests DROP COLUMN IF EXISTS closed_at;
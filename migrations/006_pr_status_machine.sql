@@ -0,0 +1,8 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP NULL;
+
+UPDATE pull_requests SET status = 'OPEN' WHERE status IS NULL;
+ALTER TABLE pull_requests ALTER COLUMN status SET NOT NULL;
+ALTER TABLE pull_requests ADD CONSTRAINT pull_requests_status_check
+    CHECK (status IN ('DRAFT', 'OPEN', 'CLOSED', 'REOPENED', 'MERGED'));
+
+CREATE INDEX IF NOT EXISTS pull_requests_status_idx ON pull_requests (status);
@@ -0,0 +1,2 @@
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS source_team;
+ALTER TABLE teams DROP COLUMN IF EXISTS fallback_teams;
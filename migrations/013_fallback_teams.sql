@@ -0,0 +1,2 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS fallback_teams TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE pr_reviewers ADD COLUMN IF NOT EXISTS source_team VARCHAR(100) NULL;
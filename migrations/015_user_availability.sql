@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS user_availability (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(50) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    reassign_reviews BOOLEAN NOT NULL DEFAULT FALSE,
+    reviews_reassigned_at TIMESTAMPTZ NULL,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS user_availability_user_idx ON user_availability (user_id, ends_at);
+CREATE INDEX IF NOT EXISTS user_availability_handoff_idx ON user_availability (starts_at)
+    WHERE reassign_reviews AND reviews_reassigned_at IS NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE teams DROP COLUMN IF EXISTS min_reviewer_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
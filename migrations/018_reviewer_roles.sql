@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT ''
+    CHECK (role IN ('', 'junior', 'mid', 'senior', 'maintainer'));
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS min_reviewer_role TEXT NOT NULL DEFAULT ''
+    CHECK (min_reviewer_role IN ('', 'junior', 'mid', 'senior', 'maintainer'));
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS pull_requests_author_idx;
+ALTER TABLE teams DROP COLUMN IF EXISTS max_consecutive_reviews;
+DROP TABLE IF EXISTS reviewer_exclusions;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS reviewer_exclusions (
+    reviewer_id VARCHAR(50) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    author_id VARCHAR(50) NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (reviewer_id, author_id),
+    CHECK (reviewer_id <> author_id)
+);
+
+CREATE INDEX IF NOT EXISTS reviewer_exclusions_author_idx ON reviewer_exclusions (author_id);
+
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS max_consecutive_reviews INTEGER NOT NULL DEFAULT 0
+    CHECK (max_consecutive_reviews >= 0);
+
+CREATE INDEX IF NOT EXISTS pull_requests_author_idx ON pull_requests (author_id, created_at);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS assignment_records (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(50) NOT NULL REFERENCES pull_requests(pull_request_id) ON DELETE CASCADE,
+    assigned_by VARCHAR(20) NOT NULL,
+    strategy VARCHAR(20) NOT NULL,
+    seed BIGINT NOT NULL,
+    inputs JSONB NOT NULL,
+    reviewers TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS assignment_records_pr_idx ON assignment_records (pull_request_id, id);
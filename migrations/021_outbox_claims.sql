@@ -0,0 +1,2 @@
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_by VARCHAR(64) NULL;
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMPTZ NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE audit_events ALTER COLUMN entity_id TYPE VARCHAR(100);
+ALTER TABLE audit_events ALTER COLUMN pull_request_id TYPE VARCHAR(50);
+ALTER TABLE assignment_records ALTER COLUMN pull_request_id TYPE VARCHAR(50);
+ALTER TABLE pr_reviewers ALTER COLUMN pull_request_id TYPE VARCHAR(50);
+ALTER TABLE pull_requests ALTER COLUMN pull_request_id TYPE VARCHAR(50);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS assignment_records_team_idx;
+ALTER TABLE assignment_records DROP COLUMN IF EXISTS rotation;
+ALTER TABLE assignment_records DROP COLUMN IF EXISTS team_name;
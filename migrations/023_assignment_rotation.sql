@@ -0,0 +1,10 @@
+ALTER TABLE assignment_records ADD COLUMN IF NOT EXISTS team_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE assignment_records ADD COLUMN IF NOT EXISTS rotation VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Older records only know where the round robin stood before them, which is
+-- the closest there is to where they left it.
+UPDATE assignment_records
+SET team_name = COALESCE(inputs->>'team', ''), rotation = COALESCE(inputs->'draw'->>'rotation', '')
+WHERE team_name = '';
+
+CREATE INDEX IF NOT EXISTS assignment_records_team_idx ON assignment_records (team_name, id);
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations into the binary.
+//
+// Each migration is a pair of files: NNN_name.sql applies it and
+// NNN_name.down.sql reverts it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingSink struct {
+	name     string
+	failures int
+	received []events.Event
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Publish(event events.Event) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink unavailable")
+	}
+	s.received = append(s.received, event)
+	return nil
+}
+
+func eventTypes(received []events.Event) []string {
+	var types []string
+	for _, event := range received {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestOutbox_RelaysEachEventOncePerSink(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "outbox-team", "outbox-1", "outbox-2", "outbox-3")
+	resp, _ := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "outbox-pr-1",
+		"pull_request_name": "Outbox PR",
+		"author_id":         "outbox-1",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	stable := &recordingSink{name: "stable"}
+	flaky := &recordingSink{name: "flaky", failures: 1}
+	relay := events.NewRelay(store, stable, flaky)
+	relay.Lease = 0
+
+	assert.NoError(t, relay.RunOnce(context.Background()))
+	assert.Equal(t, []string{events.TypePRCreated, events.TypeReviewerAssigned, events.TypeReviewerAssigned}, eventTypes(stable.received))
+	assert.Len(t, flaky.received, 2)
+
+	// The first event failed for the flaky sink only, so it stays pending and
+	// is retried there without reaching the stable sink twice.
+	pending, err := store.PendingEvents(context.Background(), "inspect", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, []string{"stable"}, pending[0].DeliveredTo)
+
+	assert.NoError(t, relay.RunOnce(context.Background()))
+	assert.Len(t, stable.received, 3)
+	assert.Len(t, flaky.received, 3)
+
+	resp, _ = postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "outbox-team"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.NoError(t, relay.RunOnce(context.Background()))
+	assert.NoError(t, relay.RunOnce(context.Background()))
+	assert.Equal(t, events.TypeTeamDeactivated, stable.received[len(stable.received)-1].Type)
+	assert.ElementsMatch(t, eventTypes(stable.received), eventTypes(flaky.received))
+
+	pending, err = store.PendingEvents(context.Background(), "inspect", 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOutbox_ClaimsEventsPerRelay(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "claim-team", "claim-1", "claim-2")
+	resp, _ := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "claim-pr-1",
+		"pull_request_name": "Claimed PR",
+		"author_id":         "claim-1",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	ctx := context.Background()
+	claimed, err := store.PendingEvents(ctx, "relay-a", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, claimed, 2)
+
+	// Another replica gets nothing while the lease holds; the owner keeps
+	// getting its claims back until they are published.
+	other, err := store.PendingEvents(ctx, "relay-b", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Empty(t, other)
+
+	again, err := store.PendingEvents(ctx, "relay-a", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, again, 2)
+
+	// An expired lease frees the events for the other replica.
+	_, err = store.PendingEvents(ctx, "relay-a", 10, 0)
+	assert.NoError(t, err)
+	other, err = store.PendingEvents(ctx, "relay-b", 10, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, other, 2)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func previewBody(prID, authorID string) map[string]interface{} {
+	return map[string]interface{}{
+		"pull_request_id":   prID,
+		"pull_request_name": prID,
+		"author_id":         authorID,
+		"required_tags":     []string{"go"},
+	}
+}
+
+func excludedUsers(body map[string]interface{}) map[interface{}]interface{} {
+	excluded := map[interface{}]interface{}{}
+	for _, entry := range body["excluded"].([]interface{}) {
+		user := entry.(map[string]interface{})
+		excluded[user["user_id"]] = user["reason"]
+	}
+	return excluded
+}
+
+func TestPreview_MatchesCreateAndWritesNothing(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "pv-team", map[string]interface{}{"assignment_strategy": "random"},
+		skilledMember{id: "pv-a"},
+		skilledMember{id: "pv-b"},
+		skilledMember{id: "pv-c"},
+		skilledMember{id: "pv-d"},
+		skilledMember{id: "pv-e", skills: map[string]int{"go": 2}},
+		skilledMember{id: "pv-f"},
+		skilledMember{id: "pv-g"},
+	)
+	postJSON(t, server.URL+"/users/setIsActive", map[string]interface{}{"user_id": "pv-b", "is_active": false})
+	now := time.Now()
+	addAvailability(t, server.URL, "pv-c", now.Add(-time.Hour), now.Add(time.Hour), false)
+	setMaxOpenReviews(t, server.URL, "pv-d", 0)
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/preview", previewBody("pv-pr", "pv-a"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[interface{}]interface{}{
+		"pv-a": "AUTHOR",
+		"pv-b": "INACTIVE",
+		"pv-c": "UNAVAILABLE",
+		"pv-d": "AT_CAPACITY",
+	}, excludedUsers(body))
+	assert.Nil(t, body["rejection"])
+
+	candidates := body["candidates"].([]interface{})
+	if assert.Len(t, candidates, 3) {
+		best := candidates[0].(map[string]interface{})
+		assert.Equal(t, "pv-e", best["user_id"], "the go reviewer scores highest")
+		assert.Equal(t, true, best["selected"])
+	}
+	reviewers := body["reviewers"]
+
+	_, again := postJSON(t, server.URL+"/pullRequest/preview", previewBody("pv-pr", "pv-a"))
+	assert.Equal(t, reviewers, again["reviewers"], "a preview leaves the next selection as it was")
+
+	getResp, err := http.Get(server.URL + "/pullRequest/get?pull_request_id=pv-pr")
+	assert.NoError(t, err)
+	getResp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, getResp.StatusCode)
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", previewBody("pv-pr", "pv-a"))
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, reviewers, body["pr"].(map[string]interface{})["assigned_reviewers"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/preview", previewBody("pv-pr", "pv-a"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorPRExists, body["rejection"].(map[string]interface{})["error"].(map[string]interface{})["code"])
+}
+
+func TestPreview_ReportsRejection(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "pr-rej", map[string]interface{}{"min_reviewer_role": "senior"},
+		skilledMember{id: "prj-a"},
+		skilledMember{id: "prj-b", role: "mid"},
+	)
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/preview", previewBody("prj-pr", "prj-a"))
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, false, body["role_met"])
+	assert.Equal(t, handlers.ErrorReviewerRoleRequired, body["rejection"].(map[string]interface{})["error"].(map[string]interface{})["code"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/preview", previewBody("prj-pr", "missing"))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorNotFound, errorCode(body))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoles_SeniorIsAssignedAndOnlyReplacedBySenior(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := addSkilledTeam(t, server.URL, "rl-team", map[string]interface{}{
+		"max_reviewers":     1,
+		"min_reviewer_role": "senior",
+	},
+		skilledMember{id: "rl-a", role: "senior"},
+		skilledMember{id: "rl-b", role: "junior"},
+		skilledMember{id: "rl-c", role: "mid"},
+		skilledMember{id: "rl-d", role: "senior"},
+		skilledMember{id: "rl-e"},
+	)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	assert.Equal(t, []interface{}{"rl-d"}, createPR(t, server.URL, "rl-pr", "rl-a"), "the only senior besides the author")
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "rl-pr",
+		"old_user_id":     "rl-d",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorReviewerRoleRequired, errorCode(body))
+
+	resp, body = postJSON(t, server.URL+"/users/setRole", map[string]interface{}{"user_id": "rl-b", "role": "maintainer"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "maintainer", body["user"].(map[string]interface{})["role"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "rl-pr",
+		"old_user_id":     "rl-d",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "rl-b", body["replaced_by"])
+}
+
+func TestRoles_NoSeniorAvailable(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "rn-team", map[string]interface{}{
+		"max_reviewers":     2,
+		"min_reviewer_role": "senior",
+	},
+		skilledMember{id: "rn-a"},
+		skilledMember{id: "rn-b", role: "junior"},
+		skilledMember{id: "rn-c", role: "mid"},
+	)
+
+	resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "rn-pr",
+		"pull_request_name": "rn-pr",
+		"author_id":         "rn-a",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorReviewerRoleRequired, errorCode(body))
+
+	resp, _ = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "rn-draft",
+		"pull_request_name": "rn-draft",
+		"author_id":         "rn-a",
+		"draft":             true,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "drafts get no reviewers, so the rule does not apply yet")
+}
+
+func TestRoles_Validation(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := addSkilledTeam(t, server.URL, "rv-team", nil, skilledMember{id: "rv-a", role: "boss"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidRole, errorCode(body))
+
+	addMemoryTeam(t, server.URL, "rv-team", "rv-a")
+
+	resp, body = postJSON(t, server.URL+"/users/setRole", map[string]interface{}{"user_id": "rv-a", "role": "boss"})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidRole, errorCode(body))
+
+	resp, _ = postJSON(t, server.URL+"/users/setRole", map[string]interface{}{"user_id": "missing", "role": "mid"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, body = postJSON(t, server.URL+"/team/settings/update", map[string]interface{}{
+		"team_name":         "rv-team",
+		"min_reviewer_role": "boss",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidSettings, errorCode(body))
+}
+
+func TestRoles_BulkDeactivationKeepsRoleRule(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "rb-dst", nil, skilledMember{id: "rb-s", role: "senior"})
+	addSkilledTeam(t, server.URL, "rb-spare", nil,
+		skilledMember{id: "rb-j", role: "junior"},
+		skilledMember{id: "rb-m", role: "maintainer"},
+	)
+	addSkilledTeam(t, server.URL, "rb-juniors", nil, skilledMember{id: "rb-k", role: "junior"})
+	addSkilledTeam(t, server.URL, "rb-src", map[string]interface{}{
+		"max_reviewers":     1,
+		"min_reviewer_role": "senior",
+		"fallback_teams":    []string{"rb-dst", "rb-spare"},
+	}, skilledMember{id: "rb-a"})
+	addSkilledTeam(t, server.URL, "rb-other", map[string]interface{}{
+		"max_reviewers":     1,
+		"min_reviewer_role": "senior",
+		"fallback_teams":    []string{"rb-dst", "rb-juniors"},
+	}, skilledMember{id: "rb-o"})
+
+	assert.Equal(t, []interface{}{"rb-s"}, createPR(t, server.URL, "rb-pr", "rb-a"))
+	assert.Equal(t, []interface{}{"rb-s"}, createPR(t, server.URL, "rb-stuck", "rb-o"))
+
+	resp, body := postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "rb-dst"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	pr, err := store.GetPRByID(context.Background(), "rb-pr")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rb-m"}, pr.AssignedReviewers, "the junior may not replace the senior")
+
+	pr, err = store.GetPRByID(context.Background(), "rb-stuck")
+	assert.NoError(t, err)
+	assert.Empty(t, pr.AssignedReviewers)
+
+	rejected := body["result"].(map[string]interface{})["rejected_prs"].(map[string]interface{})
+	assert.Len(t, rejected, 1)
+	assert.Equal(t, handlers.ErrorReviewerRoleRequired,
+		rejected["rb-stuck"].(map[string]interface{})["error"].(map[string]interface{})["code"])
+}
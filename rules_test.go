@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addExclusion(t *testing.T, serverURL, reviewerID, authorID string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	return postJSON(t, serverURL+"/rules/exclusions/add", map[string]interface{}{
+		"reviewer_id": reviewerID,
+		"author_id":   authorID,
+		"reason":      "manager",
+	})
+}
+
+func TestRules_ExcludedReviewerIsSkipped(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "ex-team", "ex-a", "ex-b", "ex-c", "ex-d")
+	resp, body := addExclusion(t, server.URL, "ex-b", "ex-a")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "manager", body["exclusion"].(map[string]interface{})["reason"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "ex-pr",
+		"pull_request_name": "ex-pr",
+		"author_id":         "ex-a",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.ElementsMatch(t, []interface{}{"ex-c", "ex-d"}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+	assert.Equal(t, map[interface{}]interface{}{"ex-b": "EXCLUDED"}, skippedUsers(body))
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "ex-pr",
+		"old_user_id":     "ex-c",
+	})
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorNoCandidate, errorCode(body))
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "ex-reverse",
+		"pull_request_name": "ex-reverse",
+		"author_id":         "ex-b",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, skippedUsers(body), "the exclusion only works one way")
+}
+
+func TestRules_ExclusionEndpoints(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "exe-team", "exe-a", "exe-b", "exe-c")
+
+	resp, body := addExclusion(t, server.URL, "exe-a", "exe-a")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidRule, errorCode(body))
+
+	resp, _ = addExclusion(t, server.URL, "exe-a", "missing")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	addExclusion(t, server.URL, "exe-a", "exe-b")
+	addExclusion(t, server.URL, "exe-c", "exe-a")
+	resp, _ = addExclusion(t, server.URL, "exe-a", "exe-b")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "adding a pair again keeps one exclusion")
+
+	listResp, err := http.Get(server.URL + "/rules/exclusions?user_id=exe-b")
+	assert.NoError(t, err)
+	json.NewDecoder(listResp.Body).Decode(&body)
+	listResp.Body.Close()
+	assert.Len(t, body["exclusions"], 1)
+
+	listResp, err = http.Get(server.URL + "/rules/exclusions")
+	assert.NoError(t, err)
+	json.NewDecoder(listResp.Body).Decode(&body)
+	listResp.Body.Close()
+	assert.Len(t, body["exclusions"], 2)
+
+	resp, _ = postJSON(t, server.URL+"/rules/exclusions/delete", map[string]interface{}{"reviewer_id": "exe-a", "author_id": "exe-b"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, _ = postJSON(t, server.URL+"/rules/exclusions/delete", map[string]interface{}{"reviewer_id": "exe-a", "author_id": "exe-b"})
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRules_ConsecutiveReviewsAreCapped(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := addTeamWithSettings(t, server.URL, "rc-team", map[string]interface{}{
+		"max_reviewers":           1,
+		"max_consecutive_reviews": 1,
+	}, "rc-a", "rc-b", "rc-c")
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	previous := createPR(t, server.URL, "rc-pr-0", "rc-a")[0]
+	for _, prID := range []string{"rc-pr-1", "rc-pr-2", "rc-pr-3"} {
+		resp, body := postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+			"pull_request_id":   prID,
+			"pull_request_name": prID,
+			"author_id":         "rc-a",
+		})
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		reviewer := body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})[0]
+		assert.NotEqual(t, previous, reviewer, "%s went to the reviewer of the previous PR", prID)
+		assert.Equal(t, map[interface{}]interface{}{previous: "RECENT_REVIEWER"}, skippedUsers(body))
+		previous = reviewer
+	}
+
+	assert.Len(t, createPR(t, server.URL, "rc-other", "rc-b"), 1, "other authors are unaffected")
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"pr-reviewer-service/internal/assignment"
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+)
+
+func setupTestServer(store storage.Store) *httptest.Server {
+	assigner := assignment.NewAssigner(time.Now().UnixNano())
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprintf(w, "PR Reviewer Service is working!")
+		} else {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/team/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddTeamHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/team/get", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GetTeamHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/team/settings", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GetTeamSettingsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/team/settings/update", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UpdateTeamSettingsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/team/codeowners", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UploadCodeownersHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/setIsActive", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SetUserActiveHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/setMaxOpenReviews", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SetUserMaxOpenReviewsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/setRole", func(w http.ResponseWriter, r *http.Request) {
+		handlers.SetUserRoleHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/availability", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListAvailabilityHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/availability/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddAvailabilityHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/availability/update", func(w http.ResponseWriter, r *http.Request) {
+		handlers.UpdateAvailabilityHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/availability/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteAvailabilityHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/rules/exclusions", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListExclusionsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/rules/exclusions/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddExclusionHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/rules/exclusions/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteExclusionHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/create", func(w http.ResponseWriter, r *http.Request) {
+		handlers.CreatePRHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/pullRequest/preview", func(w http.ResponseWriter, r *http.Request) {
+		handlers.PreviewPRHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/pullRequest/merge", func(w http.ResponseWriter, r *http.Request) {
+		handlers.MergePRHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/review", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReviewPRHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/close", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ClosePRHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/reopen", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReopenPRHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/pullRequest/ready", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReadyForReviewHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/users/getReview", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GetUserReviewsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/reassign", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ReassignReviewerHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		handlers.StatsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/users/bulkDeactivate", func(w http.ResponseWriter, r *http.Request) {
+		handlers.BulkDeactivateHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/pullRequest/get", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GetPRHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/pullRequest/explain", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ExplainPRHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/webhooks/github", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GitHubWebhookHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/webhooks/gitlab", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GitLabWebhookHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/webhooks/gitea", func(w http.ResponseWriter, r *http.Request) {
+		handlers.GiteaWebhookHandler(w, r, store, assigner)
+	})
+
+	mux.HandleFunc("/subscriptions/add", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AddSubscriptionHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/subscriptions/list", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListSubscriptionsHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/subscriptions/delete", func(w http.ResponseWriter, r *http.Request) {
+		handlers.DeleteSubscriptionHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/subscriptions/deadLetters", func(w http.ResponseWriter, r *http.Request) {
+		handlers.ListDeadLettersHandler(w, r, store)
+	})
+
+	mux.HandleFunc("/audit", func(w http.ResponseWriter, r *http.Request) {
+		handlers.AuditHandler(w, r, store)
+	})
+
+	return httptest.NewServer(handlers.Idempotency(store, mux))
+}
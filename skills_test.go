@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"pr-reviewer-service/internal/handlers"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type skilledMember struct {
+	id     string
+	skills map[string]int
+	role   string
+}
+
+func addSkilledTeam(t *testing.T, serverURL, teamName string, settings map[string]interface{}, members ...skilledMember) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	payload := []map[string]interface{}{}
+	for _, member := range members {
+		payload = append(payload, map[string]interface{}{
+			"user_id": member.id, "username": member.id, "is_active": true, "skills": member.skills, "role": member.role,
+		})
+	}
+	return postJSON(t, serverURL+"/team/add", map[string]interface{}{
+		"team_name": teamName,
+		"settings":  settings,
+		"members":   payload,
+	})
+}
+
+func createPRWithTags(t *testing.T, serverURL, prID, authorID string, tags ...string) map[string]interface{} {
+	t.Helper()
+
+	resp, body := postJSON(t, serverURL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   prID,
+		"pull_request_name": prID,
+		"author_id":         authorID,
+		"required_tags":     tags,
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	return body
+}
+
+func TestSkills_RequiredTagsAreCovered(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := addSkilledTeam(t, server.URL, "sk-team", map[string]interface{}{"max_reviewers": 2},
+		skilledMember{id: "sk-a"},
+		skilledMember{id: "sk-b", skills: map[string]int{"Go": 3}},
+		skilledMember{id: "sk-c", skills: map[string]int{"postgres": 4}},
+		skilledMember{id: "sk-d"},
+		skilledMember{id: "sk-e"},
+		skilledMember{id: "sk-f", skills: map[string]int{"postgres": 2}},
+	)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	body := createPRWithTags(t, server.URL, "sk-pr", "sk-a", " Postgres", "go", "postgres")
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"postgres", "go"}, pr["required_tags"])
+	assert.ElementsMatch(t, []interface{}{"sk-b", "sk-c"}, pr["assigned_reviewers"], "the most proficient holder is preferred")
+	assert.Equal(t, []interface{}{}, body["uncovered_tags"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/reassign", map[string]interface{}{
+		"pull_request_id": "sk-pr",
+		"old_user_id":     "sk-c",
+	})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "sk-f", body["replaced_by"], "the replacement keeps postgres covered")
+	assert.Equal(t, []interface{}{}, body["uncovered_tags"])
+}
+
+func TestSkills_UncoveredTagsAreReported(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "su-team", map[string]interface{}{"max_reviewers": 2},
+		skilledMember{id: "su-a"},
+		skilledMember{id: "su-b", skills: map[string]int{"go": 1}},
+		skilledMember{id: "su-c"},
+	)
+
+	body := createPRWithTags(t, server.URL, "su-pr", "su-a", "go", "rust")
+	assert.ElementsMatch(t, []interface{}{"su-b", "su-c"}, body["pr"].(map[string]interface{})["assigned_reviewers"])
+	assert.Equal(t, []interface{}{"rust"}, body["uncovered_tags"])
+}
+
+func TestSkills_FallbackTeamCoversMissingTag(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	addSkilledTeam(t, server.URL, "sf-partner", nil, skilledMember{id: "sf-x", skills: map[string]int{"k8s": 5}})
+	addSkilledTeam(t, server.URL, "sf-team", map[string]interface{}{
+		"max_reviewers":  2,
+		"fallback_teams": []string{"sf-partner"},
+	}, skilledMember{id: "sf-a"}, skilledMember{id: "sf-b"}, skilledMember{id: "sf-c"})
+
+	body := createPRWithTags(t, server.URL, "sf-pr", "sf-a", "k8s")
+	reviewers := body["pr"].(map[string]interface{})["assigned_reviewers"].([]interface{})
+	assert.Len(t, reviewers, 2)
+	assert.Contains(t, reviewers, "sf-x")
+	assert.Equal(t, []interface{}{}, body["uncovered_tags"])
+}
+
+func TestSkills_Validation(t *testing.T) {
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := addSkilledTeam(t, server.URL, "sv-team", nil, skilledMember{id: "sv-a", skills: map[string]int{"go": 7}})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidTags, errorCode(body))
+
+	addMemoryTeam(t, server.URL, "sv-team", "sv-a", "sv-b")
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "sv-pr",
+		"pull_request_name": "sv-pr",
+		"author_id":         "sv-a",
+		"required_tags":     []string{" "},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, handlers.ErrorInvalidTags, errorCode(body))
+}
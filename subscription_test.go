@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"pr-reviewer-service/internal/events"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func startDispatcher(t *testing.T, store storage.Store) {
+	t.Helper()
+
+	dispatcher := events.NewDispatcher(store)
+	dispatcher.MaxAttempts = 3
+	dispatcher.BaseBackoff = time.Millisecond
+	dispatcher.MaxBackoff = 5 * time.Millisecond
+	dispatcher.Start(2)
+
+	relay := events.NewRelay(store, dispatcher)
+	relay.Interval = 5 * time.Millisecond
+	relay.Start()
+
+	t.Cleanup(func() {
+		relay.Stop()
+		dispatcher.Stop()
+	})
+}
+
+func TestSubscriptions_DeliverSignedEventsWithRetries(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	startDispatcher(t, store)
+	server := setupTestServer(store)
+	defer server.Close()
+
+	var mu sync.Mutex
+	attempts := map[string]int{}
+	received := map[string]events.Event{}
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get("X-Webhook-Signature") != events.Sign("receiver-secret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		id := r.Header.Get("X-Webhook-Delivery")
+		attempts[id]++
+		if attempts[id] < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var event events.Event
+		json.Unmarshal(body, &event)
+		received[event.Type] = event
+	}))
+	defer receiver.Close()
+
+	resp, _ := postJSON(t, server.URL+"/subscriptions/add", map[string]interface{}{
+		"url":    "ftp://example.com",
+		"events": []string{events.TypePRCreated},
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, body := postJSON(t, server.URL+"/subscriptions/add", map[string]interface{}{
+		"url":    receiver.URL,
+		"secret": "receiver-secret",
+		"events": []string{events.TypePRCreated, events.TypeReviewerAssigned},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.NotContains(t, body["subscription"], "secret")
+
+	addMemoryTeam(t, server.URL, "sub-team", "sub-1", "sub-2")
+	resp, _ = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   "sub-pr-1",
+		"pull_request_name": "Subscribed PR",
+		"author_id":         "sub-1",
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, _ = postJSON(t, server.URL+"/pullRequest/merge", map[string]interface{}{"pull_request_id": "sub-pr-1"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, received, events.TypePRCreated)
+	assert.NotContains(t, received, events.TypePRMerged)
+
+	var assigned map[string]string
+	json.Unmarshal(received[events.TypeReviewerAssigned].Data, &assigned)
+	assert.Equal(t, "sub-pr-1", assigned["pull_request_id"])
+	assert.Equal(t, "sub-2", assigned["reviewer_id"])
+}
+
+func TestSubscriptions_DeadLetterAfterRetries(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	startDispatcher(t, store)
+	server := setupTestServer(store)
+	defer server.Close()
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	resp, _ := postJSON(t, server.URL+"/subscriptions/add", map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{events.TypeTeamDeactivated},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	addMemoryTeam(t, server.URL, "dead-team", "dead-1")
+	resp, _ = postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "dead-team"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var letters []interface{}
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get(server.URL + "/subscriptions/deadLetters")
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+
+		var body map[string][]interface{}
+		json.NewDecoder(resp.Body).Decode(&body)
+		letters = body["dead_letters"]
+		return len(letters) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	letter := letters[0].(map[string]interface{})
+	assert.Equal(t, events.TypeTeamDeactivated, letter["event_type"])
+	assert.Equal(t, float64(3), letter["attempts"])
+	assert.Equal(t, "unexpected status 500", letter["last_error"])
+}
+
+func TestSubscriptions_RedeliverAfterRestart(t *testing.T) {
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	var mu sync.Mutex
+	var received []string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, r.Header.Get("X-Webhook-Event"))
+	}))
+	defer receiver.Close()
+
+	resp, _ := postJSON(t, server.URL+"/subscriptions/add", map[string]interface{}{
+		"url":    receiver.URL,
+		"events": []string{events.TypeTeamDeactivated},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	addMemoryTeam(t, server.URL, "restart-team", "restart-1")
+	resp, _ = postJSON(t, server.URL+"/users/bulkDeactivate", map[string]interface{}{"team_name": "restart-team"})
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The first dispatcher stops before its workers pick up the delivery, so
+	// the event must stay in the outbox.
+	stopped := events.NewDispatcher(store)
+	relay := events.NewRelay(store, stopped)
+	relay.Lease = 0
+	assert.NoError(t, relay.RunOnce(context.Background()))
+	stopped.Stop()
+
+	pending, err := store.PendingEvents(context.Background(), "inspect", 10, 0)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	startDispatcher(t, store)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	// Later relay passes must not send it again.
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{events.TypeTeamDeactivated}, received)
+}
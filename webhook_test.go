@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"pr-reviewer-service/internal/config"
+	"pr-reviewer-service/internal/models"
+	"pr-reviewer-service/internal/storage"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func readFixture(t *testing.T, provider, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", provider, name))
+	assert.NoError(t, err)
+	return body
+}
+
+func deliverWebhook(t *testing.T, url string, body []byte, headers map[string]string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	req, _ := http.NewRequest("POST", url, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	resp.Body.Close()
+	return resp, decoded
+}
+
+func deliverGitHub(t *testing.T, serverURL, eventType, fixture, signature string) (*http.Response, map[string]interface{}) {
+	t.Helper()
+
+	body := readFixture(t, "github", fixture)
+	if signature == "" {
+		signature = sign(testWebhookSecret, body)
+	}
+
+	return deliverWebhook(t, serverURL+"/webhooks/github", body, map[string]string{
+		"X-GitHub-Event":      eventType,
+		"X-Hub-Signature-256": signature,
+	})
+}
+
+func TestGitHubWebhook_PRLifecycle(t *testing.T) {
+	config.GitHubWebhookSecret = testWebhookSecret
+	defer func() { config.GitHubWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "gh-team",
+		"settings":  map[string]interface{}{"max_reviewers": 1, "required_approvals": 1},
+		"members": []map[string]interface{}{
+			{"user_id": "gh-alice", "username": "Alice", "is_active": true, "logins": map[string]string{"github": "alice-gh"}},
+			{"user_id": "gh-bob", "username": "Bob", "is_active": true, "logins": map[string]string{"github": "bob-gh"}},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, body := deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "acme/backend#42", pr["pull_request_id"])
+	assert.Equal(t, "gh-alice", pr["author_id"])
+	assert.Equal(t, []interface{}{"gh-bob"}, pr["assigned_reviewers"])
+
+	// Redelivery of the same event must not fail or reassign.
+	resp, _ = deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, body = deliverGitHub(t, server.URL, "pull_request", "pull_request_synchronize.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ignored", body["status"])
+
+	resp, body = deliverGitHub(t, server.URL, "pull_request_review", "pull_request_review_approved.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr = body["pr"].(map[string]interface{})
+	reviews := pr["reviews"].([]interface{})
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, "APPROVED", reviews[0].(map[string]interface{})["verdict"])
+
+	resp, body = deliverGitHub(t, server.URL, "pull_request", "pull_request_closed_merged.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "merged", body["event"])
+	assert.Equal(t, "MERGED", body["pr"].(map[string]interface{})["status"])
+}
+
+// lateStore misses the first PR lookup, as a delivery racing another one that
+// stores the PR first would.
+type lateStore struct {
+	storage.Store
+	missed *atomic.Bool
+}
+
+func (s lateStore) WithActor(actor string) storage.Store {
+	return lateStore{s.Store.WithActor(actor), s.missed}
+}
+
+func (s lateStore) GetPRByID(ctx context.Context, prID string) (*models.PullRequest, error) {
+	if s.missed.CompareAndSwap(false, true) {
+		return nil, nil
+	}
+	return s.Store.GetPRByID(ctx, prID)
+}
+
+func TestGitHubWebhook_ParallelRedeliveryIsIdempotent(t *testing.T) {
+	config.GitHubWebhookSecret = testWebhookSecret
+	defer func() { config.GitHubWebhookSecret = "" }()
+
+	store := storage.NewMemoryStorage()
+	server := setupTestServer(store)
+	defer server.Close()
+
+	addMemoryTeam(t, server.URL, "gh-race", "alice-gh", "bob-gh")
+	resp, body := deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	first := body["pr"].(map[string]interface{})
+
+	late := setupTestServer(lateStore{store, new(atomic.Bool)})
+	defer late.Close()
+
+	resp, body = deliverGitHub(t, late.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, first["pull_request_id"], pr["pull_request_id"])
+	assert.Equal(t, first["assigned_reviewers"], pr["assigned_reviewers"])
+}
+
+func TestGitHubWebhook_RejectsBadSignature(t *testing.T) {
+	config.GitHubWebhookSecret = testWebhookSecret
+	defer func() { config.GitHubWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "sha256=deadbeef")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "INVALID_SIGNATURE", body["error"].(map[string]interface{})["code"])
+
+	config.GitHubWebhookSecret = ""
+	resp, _ = deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestGitHubWebhook_UnknownLogin(t *testing.T) {
+	config.GitHubWebhookSecret = testWebhookSecret
+	defer func() { config.GitHubWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, body := deliverGitHub(t, server.URL, "pull_request", "pull_request_opened.json", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "UNKNOWN_LOGIN", body["error"].(map[string]interface{})["code"])
+}
+
+func TestGitLabWebhook_MergeRequestLifecycle(t *testing.T) {
+	config.GitLabWebhookSecret = testWebhookSecret
+	defer func() { config.GitLabWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "gl-team",
+		"settings":  map[string]interface{}{"max_reviewers": 1, "required_approvals": 1},
+		"members": []map[string]interface{}{
+			{"user_id": "gl-alice", "username": "Alice", "is_active": true, "logins": map[string]string{"gitlab": "alice-gl"}},
+			{"user_id": "gl-bob", "username": "Bob", "is_active": true, "logins": map[string]string{"gitlab": "bob-gl"}},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	deliver := func(fixture, token string) (*http.Response, map[string]interface{}) {
+		return deliverWebhook(t, server.URL+"/webhooks/gitlab", readFixture(t, "gitlab", fixture), map[string]string{
+			"X-Gitlab-Event": "Merge Request Hook",
+			"X-Gitlab-Token": token,
+		})
+	}
+
+	resp, _ = deliver("merge_request_open.json", "wrong-token")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := deliver("merge_request_open.json", testWebhookSecret)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "platform/billing#7", pr["pull_request_id"])
+	assert.Equal(t, "gl-alice", pr["author_id"])
+	assert.Equal(t, []interface{}{"gl-bob"}, pr["assigned_reviewers"])
+
+	resp, body = deliver("merge_request_approved.json", testWebhookSecret)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Len(t, body["pr"].(map[string]interface{})["reviews"], 1)
+
+	resp, body = deliver("merge_request_merge.json", testWebhookSecret)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "MERGED", body["pr"].(map[string]interface{})["status"])
+}
+
+func TestGitLabWebhook_DraftMarkedReady(t *testing.T) {
+	config.GitLabWebhookSecret = testWebhookSecret
+	defer func() { config.GitLabWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "gl-draft",
+		"settings":  map[string]interface{}{"max_reviewers": 1},
+		"members": []map[string]interface{}{
+			{"user_id": "gl-draft-alice", "username": "Alice", "is_active": true, "logins": map[string]string{"gitlab": "alice-gl"}},
+			{"user_id": "gl-draft-bob", "username": "Bob", "is_active": true, "logins": map[string]string{"gitlab": "bob-gl"}},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	deliver := func(fixture string) (*http.Response, map[string]interface{}) {
+		return deliverWebhook(t, server.URL+"/webhooks/gitlab", readFixture(t, "gitlab", fixture), map[string]string{
+			"X-Gitlab-Event": "Merge Request Hook",
+			"X-Gitlab-Token": testWebhookSecret,
+		})
+	}
+
+	resp, body := deliver("merge_request_draft_open.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "DRAFT", pr["status"])
+	assert.Empty(t, pr["assigned_reviewers"])
+
+	// Other updates leave the draft alone.
+	resp, body = deliver("merge_request_update_title.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "ignored", body["status"])
+
+	resp, body = deliver("merge_request_update_ready.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr = body["pr"].(map[string]interface{})
+	assert.Equal(t, "platform/billing#8", pr["pull_request_id"])
+	assert.Equal(t, "OPEN", pr["status"])
+	assert.Equal(t, []interface{}{"gl-draft-bob"}, pr["assigned_reviewers"])
+}
+
+// withProjectPath swaps the GitLab project path in a fixture.
+func withProjectPath(t *testing.T, fixture []byte, path string) []byte {
+	t.Helper()
+
+	var payload map[string]interface{}
+	assert.NoError(t, json.Unmarshal(fixture, &payload))
+	payload["project"].(map[string]interface{})["path_with_namespace"] = path
+	body, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	return body
+}
+
+func TestGitLabWebhook_LongProjectPath(t *testing.T) {
+	config.GitLabWebhookSecret = testWebhookSecret
+	defer func() { config.GitLabWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "gl-long",
+		"members": []map[string]interface{}{
+			{"user_id": "gl-long-alice", "username": "Alice", "is_active": true, "logins": map[string]string{"gitlab": "alice-gl"}},
+			{"user_id": "gl-long-bob", "username": "Bob", "is_active": true, "logins": map[string]string{"gitlab": "bob-gl"}},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	deliver := func(fixture, path string) (*http.Response, map[string]interface{}) {
+		body := withProjectPath(t, readFixture(t, "gitlab", fixture), path)
+		return deliverWebhook(t, server.URL+"/webhooks/gitlab", body, map[string]string{
+			"X-Gitlab-Event": "Merge Request Hook",
+			"X-Gitlab-Token": testWebhookSecret,
+		})
+	}
+
+	// Nested groups make paths longer than a PR id may be; two paths that
+	// differ only past the cut must still get different ids.
+	group := strings.Repeat("platform-group/", 20)
+	first, second := group+"billing", group+"invoices"
+
+	resp, body := deliver("merge_request_open.json", first)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	firstID := body["pr"].(map[string]interface{})["pull_request_id"].(string)
+	assert.LessOrEqual(t, len(firstID), models.MaxPullRequestIDLength)
+	assert.True(t, strings.HasSuffix(firstID, "#7"))
+
+	resp, body = deliver("merge_request_open.json", second)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, firstID, body["pr"].(map[string]interface{})["pull_request_id"])
+
+	resp, body = deliver("merge_request_merge.json", first)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, firstID, pr["pull_request_id"])
+	assert.Equal(t, "MERGED", pr["status"])
+
+	resp, body = postJSON(t, server.URL+"/pullRequest/create", map[string]interface{}{
+		"pull_request_id":   first + "#7",
+		"pull_request_name": "Too long",
+		"author_id":         "gl-long-alice",
+	})
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_PR_ID", body["error"].(map[string]interface{})["code"])
+}
+
+func TestGiteaWebhook_OpenAndClose(t *testing.T) {
+	config.GiteaWebhookSecret = testWebhookSecret
+	defer func() { config.GiteaWebhookSecret = "" }()
+
+	server := setupTestServer(storage.NewMemoryStorage())
+	defer server.Close()
+
+	resp, _ := postJSON(t, server.URL+"/team/add", map[string]interface{}{
+		"team_name": "gt-team",
+		"members": []map[string]interface{}{
+			{"user_id": "gt-alice", "username": "Alice", "is_active": true, "logins": map[string]string{"gitea": "alice-gt"}},
+			{"user_id": "gt-bob", "username": "Bob", "is_active": true},
+		},
+	})
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	deliver := func(fixture string) (*http.Response, map[string]interface{}) {
+		body := readFixture(t, "gitea", fixture)
+		mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+		mac.Write(body)
+		return deliverWebhook(t, server.URL+"/webhooks/gitea", body, map[string]string{
+			"X-Gitea-Event":     "pull_request",
+			"X-Gitea-Signature": hex.EncodeToString(mac.Sum(nil)),
+		})
+	}
+
+	resp, body := deliver("pull_request_opened.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	pr := body["pr"].(map[string]interface{})
+	assert.Equal(t, "web/portal#3", pr["pull_request_id"])
+	assert.Equal(t, "gt-alice", pr["author_id"])
+
+	resp, body = deliver("pull_request_closed.json")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "closed", body["event"])
+	assert.Equal(t, "CLOSED", body["pr"].(map[string]interface{})["status"])
+}